@@ -0,0 +1,786 @@
+package bridge
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bufio"
+	"bytes"
+	"compress/bzip2"
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"log"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/klauspost/compress/zstd"
+	"github.com/ulikunitz/xz"
+)
+
+var (
+	zipMagic   = []byte("PK\x03\x04")
+	gzipMagic  = []byte{0x1f, 0x8b}
+	xzMagic    = []byte{0xfd, '7', 'z', 'X', 'Z', 0x00}
+	zstdMagic  = []byte{0x28, 0xb5, 0x2f, 0xfd}
+	bzip2Magic = []byte("BZh")
+)
+
+func (a *App) ZipFiles(sources []string, output string, options ArchiveOptions) FlagResult {
+	log.Printf("ZipFiles: %v -> %s", sources, output)
+
+	outputPath, err := sandboxPath(output, options.Token)
+	if err != nil {
+		return FlagResult{false, err.Error()}
+	}
+
+	err = createArchive(outputPath, func(w io.Writer) error {
+		zw := zip.NewWriter(w)
+
+		err := walkArchiveSources(sources, outputPath, options, func(fullPath string, name string, info fs.FileInfo) error {
+			header, err := zip.FileInfoHeader(info)
+			if err != nil {
+				return err
+			}
+			header.Name = name
+			if info.IsDir() {
+				header.Name += "/"
+			} else {
+				header.Method = zip.Deflate
+			}
+
+			entry, err := zw.CreateHeader(header)
+			if err != nil || info.IsDir() {
+				return err
+			}
+
+			if info.Mode()&os.ModeSymlink != 0 {
+				target, err := os.Readlink(fullPath)
+				if err != nil {
+					return err
+				}
+				_, err = io.WriteString(entry, filepath.ToSlash(target))
+				return err
+			}
+
+			return copyFileTo(entry, fullPath)
+		})
+		if err != nil {
+			zw.Close()
+			return err
+		}
+
+		return zw.Close()
+	})
+	if err != nil {
+		return FlagResult{false, err.Error()}
+	}
+
+	return FlagResult{true, "Success"}
+}
+
+func (a *App) TarGzFiles(sources []string, output string, options ArchiveOptions) FlagResult {
+	log.Printf("TarGzFiles: %v -> %s", sources, output)
+
+	outputPath, err := sandboxPath(output, options.Token)
+	if err != nil {
+		return FlagResult{false, err.Error()}
+	}
+
+	err = createArchive(outputPath, func(w io.Writer) error {
+		gw := gzip.NewWriter(w)
+		tw := tar.NewWriter(gw)
+
+		err := walkArchiveSources(sources, outputPath, options, func(fullPath string, name string, info fs.FileInfo) error {
+			var link string
+			if info.Mode()&os.ModeSymlink != 0 {
+				target, err := os.Readlink(fullPath)
+				if err != nil {
+					return err
+				}
+				link = filepath.ToSlash(target)
+			}
+
+			header, err := tar.FileInfoHeader(info, link)
+			if err != nil {
+				return err
+			}
+			header.Name = name
+			if info.IsDir() {
+				header.Name += "/"
+			}
+
+			if err := tw.WriteHeader(header); err != nil {
+				return err
+			}
+			if !info.Mode().IsRegular() {
+				return nil
+			}
+
+			return copyFileTo(tw, fullPath)
+		})
+		if err == nil {
+			err = tw.Close()
+		}
+		if err == nil {
+			err = gw.Close()
+		}
+		return err
+	})
+	if err != nil {
+		return FlagResult{false, err.Error()}
+	}
+
+	return FlagResult{true, "Success"}
+}
+
+// ExtractArchive extracts zip, tar (optionally gzip, xz, zstd or bzip2 compressed) and
+// single gzip, xz, zstd or bzip2 compressed files into the output directory. The format
+// is detected from the content, not the file name.
+func (a *App) ExtractArchive(path string, output string, options ExtractOptions) FlagResult {
+	log.Printf("ExtractArchive: %s -> %s", path, output)
+
+	fullPath, err := sandboxPath(path, options.Token)
+	if err != nil {
+		return FlagResult{false, err.Error()}
+	}
+	outputPath, err := sandboxPath(output, options.Token)
+	if err != nil {
+		return FlagResult{false, err.Error()}
+	}
+
+	if err := validateGlobs(options.Include); err != nil {
+		return FlagResult{false, err.Error()}
+	}
+
+	x, err := newArchiveExtractor(a, outputPath, options)
+	if err != nil {
+		return FlagResult{false, err.Error()}
+	}
+
+	if err := x.extract(fullPath); err != nil {
+		return FlagResult{false, err.Error()}
+	}
+
+	return FlagResult{true, "Success"}
+}
+
+var errExtractSizeLimit = errors.New("archive exceeds the extraction size limit")
+
+type archiveExtractor struct {
+	app     *App
+	output  string
+	root    string // output with symlinks resolved
+	options ExtractOptions
+	maxSize int64
+	written int64
+	failed  []error
+}
+
+func newArchiveExtractor(a *App, outputPath string, options ExtractOptions) (*archiveExtractor, error) {
+	if err := os.MkdirAll(outputPath, os.ModePerm); err != nil {
+		return nil, err
+	}
+
+	root, err := filepath.EvalSymlinks(outputPath)
+	if err != nil {
+		return nil, err
+	}
+
+	maxSize := options.MaxSize
+	if maxSize == 0 {
+		maxSize = 2 << 30
+	}
+
+	return &archiveExtractor{
+		app:     a,
+		output:  outputPath,
+		root:    root,
+		options: options,
+		maxSize: maxSize,
+	}, nil
+}
+
+// extract detects the format of the file and extracts it. Unless FailFast is set, entries
+// that cannot be written are collected and reported together once the archive has been
+// fully processed; exceeding the size limit always aborts.
+func (x *archiveExtractor) extract(fullPath string) error {
+	file, err := os.Open(fullPath)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	head := make([]byte, 512)
+	n, err := io.ReadFull(file, head)
+	if err != nil && !errors.Is(err, io.ErrUnexpectedEOF) && !errors.Is(err, io.EOF) {
+		return err
+	}
+	head = head[:n]
+
+	if bytes.HasPrefix(head, zipMagic) {
+		return x.extractZip(fullPath)
+	}
+
+	stat, err := file.Stat()
+	if err != nil {
+		return err
+	}
+	if _, err := file.Seek(0, io.SeekStart); err != nil {
+		return err
+	}
+
+	// progress of streamed formats follows the compressed input, the uncompressed size is unknown upfront
+	input := wrapWithProgress(file, stat.Size(), x.options.Event, x.app)
+
+	name := filepath.Base(fullPath)
+	var r io.Reader
+
+	switch {
+	case bytes.HasPrefix(head, gzipMagic):
+		gzipReader, err := gzip.NewReader(input)
+		if err != nil {
+			return err
+		}
+		defer gzipReader.Close()
+		if gzipReader.Name != "" {
+			name = gzipReader.Name
+		}
+		r = gzipReader
+	case bytes.HasPrefix(head, xzMagic):
+		xzReader, err := xz.NewReader(input)
+		if err != nil {
+			return err
+		}
+		r = xzReader
+	case bytes.HasPrefix(head, zstdMagic):
+		zstdReader, err := zstd.NewReader(input)
+		if err != nil {
+			return err
+		}
+		defer zstdReader.Close()
+		r = zstdReader
+	case bytes.HasPrefix(head, bzip2Magic):
+		r = bzip2.NewReader(input)
+	case isTarHeader(head):
+		return x.extractTar(tar.NewReader(input))
+	default:
+		return errors.New("unsupported archive format")
+	}
+
+	buffered := bufio.NewReader(r)
+	if peek, _ := buffered.Peek(512); isTarHeader(peek) {
+		return x.extractTar(tar.NewReader(buffered))
+	}
+
+	name = trimCompressionExt(name)
+	err = x.writeEntry(name, 0644, "", func() (io.ReadCloser, error) {
+		return io.NopCloser(buffered), nil
+	})
+	if err := x.record(name, err); err != nil {
+		return err
+	}
+
+	return x.result()
+}
+
+func (x *archiveExtractor) extractZip(fullPath string) error {
+	archive, err := zip.OpenReader(fullPath)
+	if err != nil {
+		return err
+	}
+	defer archive.Close()
+
+	var total int64
+	for _, f := range archive.File {
+		total += int64(f.UncompressedSize64)
+	}
+	if x.maxSize > 0 && total > x.maxSize {
+		return errExtractSizeLimit
+	}
+
+	tracker := &WriteTracker{
+		Total:          total,
+		EmitThreshold:  128 * 1024,
+		ProgressChange: x.options.Event,
+		App:            x.app,
+	}
+
+	for _, f := range archive.File {
+		open := f.Open
+		if x.options.Event != "" {
+			open = func() (io.ReadCloser, error) {
+				rc, err := f.Open()
+				if err != nil {
+					return nil, err
+				}
+				return struct {
+					io.Reader
+					io.Closer
+				}{io.TeeReader(rc, tracker), rc}, nil
+			}
+		}
+
+		var linkname string
+		if f.Mode()&os.ModeSymlink != 0 {
+			linkname, err = readZipSymlink(f)
+			if err := x.record(f.Name, err); err != nil {
+				return err
+			}
+			if err != nil {
+				continue
+			}
+		}
+
+		err := x.writeEntry(f.Name, f.Mode(), linkname, open)
+		if err := x.record(f.Name, err); err != nil {
+			return err
+		}
+	}
+
+	return x.result()
+}
+
+func (x *archiveExtractor) extractTar(tarReader *tar.Reader) error {
+	for {
+		header, err := tarReader.Next()
+		if err == io.EOF {
+			return x.result()
+		}
+		if err != nil {
+			return err
+		}
+
+		if header.Typeflag == tar.TypeLink {
+			err = x.writeHardlink(header.Name, header.Linkname)
+		} else {
+			err = x.writeEntry(header.Name, header.FileInfo().Mode(), header.Linkname, func() (io.ReadCloser, error) {
+				return io.NopCloser(tarReader), nil
+			})
+		}
+		if err := x.record(header.Name, err); err != nil {
+			return err
+		}
+	}
+}
+
+// record keeps a failed entry for the final report, or returns the error when
+// extraction should stop right away
+func (x *archiveExtractor) record(entryName string, err error) error {
+	if err == nil {
+		return nil
+	}
+	err = fmt.Errorf("%s: %w", entryName, err)
+	if x.options.FailFast || errors.Is(err, errExtractSizeLimit) {
+		return err
+	}
+	x.failed = append(x.failed, err)
+	return nil
+}
+
+func (x *archiveExtractor) result() error {
+	if len(x.failed) == 0 {
+		return nil
+	}
+	return fmt.Errorf("%d entries failed to extract:\n%w", len(x.failed), errors.Join(x.failed...))
+}
+
+// writeEntry creates a directory, regular file or symlink for one archive entry. open is
+// only called for files that are actually extracted.
+func (x *archiveExtractor) writeEntry(entryName string, mode fs.FileMode, linkname string, open func() (io.ReadCloser, error)) error {
+	isSymlink := mode&os.ModeSymlink != 0
+	if !mode.IsDir() && !mode.IsRegular() && !isSymlink {
+		log.Printf("ExtractArchive: skipping %s, unsupported entry type %v", entryName, mode.Type())
+		return nil
+	}
+
+	name, ok := x.entryName(entryName)
+	if !ok {
+		return nil
+	}
+
+	target, err := x.targetPath(name)
+	if err != nil {
+		return err
+	}
+
+	if mode.IsDir() {
+		return os.MkdirAll(target, os.ModePerm)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(target), os.ModePerm); err != nil {
+		return err
+	}
+
+	if isSymlink {
+		return x.writeSymlink(target, linkname)
+	}
+
+	src, err := open()
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+
+	perm := mode.Perm()
+	if perm == 0 {
+		perm = 0644
+	}
+
+	// an existing symlink would otherwise be followed when the file is opened
+	if stat, err := os.Lstat(target); err == nil && stat.Mode()&os.ModeSymlink != 0 {
+		if err := os.Remove(target); err != nil {
+			return err
+		}
+	}
+
+	dst, err := os.OpenFile(target, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, perm)
+	if err != nil {
+		return err
+	}
+
+	var reader io.Reader = src
+	if x.maxSize > 0 {
+		reader = io.LimitReader(src, x.maxSize-x.written+1)
+	}
+
+	n, err := io.Copy(dst, reader)
+	x.written += n
+	if err == nil && x.maxSize > 0 && x.written > x.maxSize {
+		err = errExtractSizeLimit
+	}
+	if err != nil {
+		dst.Close()
+		os.Remove(target)
+		return err
+	}
+
+	if err := dst.Close(); err != nil {
+		return err
+	}
+
+	// the file may have existed with other permissions and the umask applies on creation
+	return os.Chmod(target, perm)
+}
+
+// writeSymlink only creates links whose target stays inside the output directory,
+// resolved from the real location of the link so earlier links cannot be chained to escape
+func (x *archiveExtractor) writeSymlink(target string, linkname string) error {
+	if linkname == "" || filepath.IsAbs(linkname) || path.IsAbs(linkname) {
+		return errors.New("unsafe symlink target: " + linkname)
+	}
+
+	dir, err := resolveExistingPath(filepath.Dir(target))
+	if err != nil {
+		return err
+	}
+	if !pathWithin(x.root, filepath.Join(dir, filepath.FromSlash(linkname))) {
+		return errors.New("unsafe symlink target: " + linkname)
+	}
+
+	if err := os.RemoveAll(target); err != nil {
+		return err
+	}
+
+	return os.Symlink(filepath.FromSlash(linkname), target)
+}
+
+func (x *archiveExtractor) writeHardlink(entryName string, linkname string) error {
+	name, ok := x.entryName(entryName)
+	if !ok {
+		return nil
+	}
+
+	target, err := x.targetPath(name)
+	if err != nil {
+		return err
+	}
+
+	// hard link names are relative to the archive root, so strip them the same way
+	linkName, ok := x.stripComponents(linkname)
+	if !ok {
+		return errors.New("unsafe hard link target: " + linkname)
+	}
+	source, err := x.targetPath(linkName)
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(target), os.ModePerm); err != nil {
+		return err
+	}
+	if err := os.RemoveAll(target); err != nil {
+		return err
+	}
+
+	return os.Link(source, target)
+}
+
+// targetPath maps a stripped entry name into the output directory, rejecting names that
+// escape it either lexically or through a symlink already present on disk
+func (x *archiveExtractor) targetPath(name string) (string, error) {
+	target, ok := archiveEntryPath(x.output, name)
+	if !ok {
+		return "", errors.New("unsafe entry path")
+	}
+
+	resolved, err := resolveExistingPath(filepath.Dir(target))
+	if err != nil {
+		return "", err
+	}
+	if !pathWithin(x.root, resolved) {
+		return "", errors.New("unsafe entry path")
+	}
+
+	return target, nil
+}
+
+// entryName applies StripComponents and the include globs to an entry name
+func (x *archiveExtractor) entryName(entryName string) (string, bool) {
+	name, ok := x.stripComponents(entryName)
+	if !ok {
+		return "", false
+	}
+
+	if len(x.options.Include) > 0 && !matchAnyGlob(x.options.Include, name) {
+		return "", false
+	}
+
+	return name, true
+}
+
+func (x *archiveExtractor) stripComponents(entryName string) (string, bool) {
+	name := path.Clean(strings.ReplaceAll(entryName, "\\", "/"))
+	elements := strings.Split(strings.TrimPrefix(name, "/"), "/")
+	if len(elements) <= x.options.StripComponents {
+		return "", false
+	}
+	return strings.Join(elements[x.options.StripComponents:], "/"), true
+}
+
+func readZipSymlink(f *zip.File) (string, error) {
+	rc, err := f.Open()
+	if err != nil {
+		return "", err
+	}
+	defer rc.Close()
+
+	b, err := io.ReadAll(io.LimitReader(rc, 4096))
+	if err != nil {
+		return "", err
+	}
+	return string(b), nil
+}
+
+// resolveExistingPath resolves symlinks in the longest existing prefix of p and appends
+// the remaining, not yet created elements
+func resolveExistingPath(p string) (string, error) {
+	var rest []string
+	for {
+		resolved, err := filepath.EvalSymlinks(p)
+		if err == nil {
+			return filepath.Join(append([]string{resolved}, rest...)...), nil
+		}
+		if !os.IsNotExist(err) {
+			return "", err
+		}
+		parent := filepath.Dir(p)
+		if parent == p {
+			return "", err
+		}
+		rest = append([]string{filepath.Base(p)}, rest...)
+		p = parent
+	}
+}
+
+func pathWithin(root string, p string) bool {
+	rel, err := filepath.Rel(root, p)
+	return err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator))
+}
+
+func isTarHeader(head []byte) bool {
+	return len(head) >= 262 && string(head[257:262]) == "ustar"
+}
+
+func trimCompressionExt(name string) string {
+	for _, ext := range []string{".gz", ".xz", ".zst", ".bz2"} {
+		if trimmed, ok := strings.CutSuffix(name, ext); ok && trimmed != "" {
+			return trimmed
+		}
+	}
+	return name
+}
+
+// createArchive runs write against a freshly created output file and removes the
+// partial file when anything goes wrong
+func createArchive(outputPath string, write func(w io.Writer) error) error {
+	if err := os.MkdirAll(filepath.Dir(outputPath), os.ModePerm); err != nil {
+		return err
+	}
+
+	file, err := os.Create(outputPath)
+	if err != nil {
+		return err
+	}
+
+	if err := write(file); err != nil {
+		file.Close()
+		os.Remove(outputPath)
+		return err
+	}
+	if err := file.Close(); err != nil {
+		os.Remove(outputPath)
+		return err
+	}
+
+	return nil
+}
+
+// walkArchiveSources calls add for every file, directory and symlink below the sources
+// that passes the include/exclude globs, with its slash separated entry name. Excluded
+// directories are skipped entirely and the archive being written is never added to itself.
+// Pipes, sockets and devices are skipped too: zip has no entries for them, tar cannot
+// store sockets, and reading a pipe could block forever.
+func walkArchiveSources(sources []string, outputPath string, options ArchiveOptions, add func(fullPath string, name string, info fs.FileInfo) error) error {
+	if len(sources) == 0 {
+		return errors.New("no source files")
+	}
+
+	if err := validateGlobs(options.Include); err != nil {
+		return err
+	}
+	if err := validateGlobs(options.Exclude); err != nil {
+		return err
+	}
+
+	baseDir := ""
+	if options.BaseDir != "" {
+		baseDir = resolvePath(options.BaseDir)
+	}
+
+	for _, source := range sources {
+		sourcePath, err := sandboxPath(source, options.Token)
+		if err != nil {
+			return err
+		}
+
+		base := baseDir
+		if base == "" {
+			base = filepath.Dir(sourcePath)
+		}
+
+		err = filepath.WalkDir(sourcePath, func(fullPath string, d fs.DirEntry, err error) error {
+			if err != nil {
+				return err
+			}
+			if filepath.ToSlash(fullPath) == outputPath {
+				return nil
+			}
+
+			rel, err := filepath.Rel(base, fullPath)
+			if err != nil {
+				return err
+			}
+			name := filepath.ToSlash(rel)
+			if name == "." {
+				return nil
+			}
+			if name == ".." || strings.HasPrefix(name, "../") {
+				return errors.New(source + " is outside of " + options.BaseDir)
+			}
+
+			if matchAnyGlob(options.Exclude, name) {
+				if d.IsDir() {
+					return filepath.SkipDir
+				}
+				return nil
+			}
+			// with include globs only matching files are stored, their directories are implied
+			if len(options.Include) > 0 && (d.IsDir() || !matchAnyGlob(options.Include, name)) {
+				return nil
+			}
+
+			info, err := d.Info()
+			if err != nil {
+				return err
+			}
+
+			mode := info.Mode()
+			if !mode.IsDir() && !mode.IsRegular() && mode&os.ModeSymlink == 0 {
+				log.Printf("Archive: skipping %s, unsupported file type %v", fullPath, mode.Type())
+				return nil
+			}
+
+			return add(fullPath, name, info)
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func copyFileTo(w io.Writer, path string) error {
+	file, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	_, err = io.Copy(w, file)
+	return err
+}
+
+func validateGlobs(patterns []string) error {
+	for _, pattern := range patterns {
+		if _, err := path.Match(pattern, ""); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func matchAnyGlob(patterns []string, name string) bool {
+	for _, pattern := range patterns {
+		if matchGlob(pattern, name) {
+			return true
+		}
+	}
+	return false
+}
+
+// matchGlob matches a slash separated name against pattern. A pattern without a slash
+// is matched against the last element only, like .gitignore; otherwise it is matched
+// against the whole name and a "**" element matches any number of elements.
+func matchGlob(pattern string, name string) bool {
+	if !strings.Contains(pattern, "/") {
+		matched, _ := path.Match(pattern, path.Base(name))
+		return matched
+	}
+	return matchGlobElements(strings.Split(pattern, "/"), strings.Split(name, "/"))
+}
+
+func matchGlobElements(pattern []string, name []string) bool {
+	for len(pattern) > 0 {
+		if pattern[0] == "**" {
+			for i := 0; i <= len(name); i++ {
+				if matchGlobElements(pattern[1:], name[i:]) {
+					return true
+				}
+			}
+			return false
+		}
+		if len(name) == 0 {
+			return false
+		}
+		if matched, _ := path.Match(pattern[0], name[0]); !matched {
+			return false
+		}
+		pattern, name = pattern[1:], name[1:]
+	}
+	return len(name) == 0
+}
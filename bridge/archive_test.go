@@ -0,0 +1,515 @@
+package bridge
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"encoding/base64"
+	"io"
+	"io/fs"
+	"net"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/klauspost/compress/zstd"
+	"github.com/ulikunitz/xz"
+)
+
+type testArchiveEntry struct {
+	name     string
+	body     string
+	link     string // symlink target
+	hardlink string // hard link target, tar only
+	mode     int64  // permissions, 0644 when zero, tar only
+}
+
+func writeTestTar(t *testing.T, path string, entries []testArchiveEntry) {
+	t.Helper()
+
+	if err := os.WriteFile(path, testTar(t, entries), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func testTar(t *testing.T, entries []testArchiveEntry) []byte {
+	t.Helper()
+
+	var buf bytes.Buffer
+	tw := tar.NewWriter(&buf)
+	for _, e := range entries {
+		header := &tar.Header{Name: e.name, Mode: 0644, Size: int64(len(e.body)), Typeflag: tar.TypeReg}
+		switch {
+		case e.link != "":
+			header = &tar.Header{Name: e.name, Mode: 0777, Linkname: e.link, Typeflag: tar.TypeSymlink}
+		case e.hardlink != "":
+			header = &tar.Header{Name: e.name, Mode: 0644, Linkname: e.hardlink, Typeflag: tar.TypeLink}
+		case e.mode != 0:
+			header.Mode = e.mode
+		}
+		if err := tw.WriteHeader(header); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := tw.Write([]byte(e.body)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := tw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func writeTestZip(t *testing.T, path string, entries []testArchiveEntry) {
+	t.Helper()
+
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	for _, e := range entries {
+		header := &zip.FileHeader{Name: e.name, Method: zip.Store}
+		header.SetMode(0644)
+		body := e.body
+		if e.link != "" {
+			header.SetMode(os.ModeSymlink | 0777)
+			body = e.link
+		}
+		w, err := zw.CreateHeader(header)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := w.Write([]byte(body)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, buf.Bytes(), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestExtractArchivePathTraversal(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("symlinks need extra privileges")
+	}
+
+	entries := []testArchiveEntry{
+		{name: "ok.txt", body: "ok"},
+		{name: "../evil.txt", body: "evil"},
+		{name: "dir/../../evil.txt", body: "evil"},
+		{name: "up", link: ".."},
+		{name: "up/evil.txt", body: "evil"},
+		{name: "abs", link: "/tmp"},
+		{name: "pre/evil.txt", body: "evil"},
+		{name: "inside", link: "ok.txt"},
+	}
+
+	for _, format := range []string{"tar", "zip"} {
+		t.Run(format, func(t *testing.T) {
+			dir := useSandbox(t)
+			out := filepath.Join(dir, "out")
+			outside := filepath.Join(dir, "outside")
+			os.MkdirAll(out, os.ModePerm)
+			os.MkdirAll(outside, os.ModePerm)
+			// a link already on disk must not be followed either
+			if err := os.Symlink(outside, filepath.Join(out, "pre")); err != nil {
+				t.Fatal(err)
+			}
+
+			archive := filepath.Join(dir, "test."+format)
+			if format == "tar" {
+				writeTestTar(t, archive, entries)
+			} else {
+				writeTestZip(t, archive, entries)
+			}
+
+			result := (&App{}).ExtractArchive(archive, out, ExtractOptions{})
+			if result.Flag {
+				t.Fatal("expected the unsafe entries to be reported")
+			}
+			if !strings.Contains(result.Data, "5 entries failed") {
+				t.Errorf("result = %q", result.Data)
+			}
+
+			for _, path := range []string{filepath.Join(dir, "evil.txt"), filepath.Join(outside, "evil.txt")} {
+				if _, err := os.Lstat(path); err == nil {
+					t.Errorf("%s was written outside of the output directory", path)
+				}
+			}
+			if b, err := os.ReadFile(filepath.Join(out, "inside")); err != nil || string(b) != "ok" {
+				t.Errorf("inside = %q, %v", b, err)
+			}
+		})
+	}
+}
+
+// specialFilesSource creates a source directory holding a file, a symlink, a named pipe
+// and a unix socket, returning the sandbox and the source directory
+func specialFilesSource(t *testing.T) (string, string) {
+	t.Helper()
+	if runtime.GOOS == "windows" {
+		t.Skip("needs mkfifo")
+	}
+
+	dir := useSandbox(t)
+	src := filepath.Join(dir, "src")
+	os.MkdirAll(src, os.ModePerm)
+	os.WriteFile(filepath.Join(src, "file.txt"), []byte("data"), 0644)
+	os.Symlink("file.txt", filepath.Join(src, "link"))
+	if out, err := exec.Command("mkfifo", filepath.Join(src, "fifo")).CombinedOutput(); err != nil {
+		t.Skipf("mkfifo: %v %s", err, out)
+	}
+	listener, err := net.Listen("unix", filepath.Join(src, "sock"))
+	if err != nil {
+		t.Skipf("unix socket: %v", err)
+	}
+	t.Cleanup(func() { listener.Close() })
+
+	return dir, src
+}
+
+func TestZipFilesSkipsSpecialFiles(t *testing.T) {
+	dir, src := specialFilesSource(t)
+
+	output := filepath.Join(dir, "out.zip")
+	if result := (&App{}).ZipFiles([]string{src}, output, ArchiveOptions{}); !result.Flag {
+		t.Fatal(result.Data)
+	}
+
+	archive, err := zip.OpenReader(output)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer archive.Close()
+
+	var names []string
+	for _, f := range archive.File {
+		names = append(names, f.Name)
+	}
+	if got := strings.Join(names, ","); got != "src/,src/file.txt,src/link" {
+		t.Errorf("entries = %s", got)
+	}
+}
+
+func TestTarGzFilesSkipsSpecialFiles(t *testing.T) {
+	dir, src := specialFilesSource(t)
+
+	output := filepath.Join(dir, "out.tar.gz")
+	if result := (&App{}).TarGzFiles([]string{src}, output, ArchiveOptions{}); !result.Flag {
+		t.Fatal(result.Data)
+	}
+
+	file, err := os.Open(output)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+	gr, err := gzip.NewReader(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tr := tar.NewReader(gr)
+
+	var names []string
+	for {
+		header, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		names = append(names, header.Name)
+	}
+	if got := strings.Join(names, ","); got != "src/,src/file.txt,src/link" {
+		t.Errorf("entries = %s", got)
+	}
+}
+
+// bzip2 compressed tar holding root/file.txt ("packed\n"), the standard library has no bzip2 writer
+const testTarBz2 = "QlpoOTFBWSZTWXR3SQYAAHV7gMmQAAhAAeeACABvLN5ACAggAFRBRoyNBhDI2kbUElE0aGhoAAB93AahA9yEIs6jOXQoegQwM2mlUZROYIxuEItURcEp5BXKTTOdKH1VMzsmCWR8REQD8XckU4UJB0d0kGA="
+
+// extractTestArchive writes content to a file named name in a fresh sandbox and extracts
+// it into out, returning the output directory and the result
+func extractTestArchive(t *testing.T, name string, content []byte, options ExtractOptions) (string, FlagResult) {
+	t.Helper()
+
+	dir := useSandbox(t)
+	archive := filepath.Join(dir, name)
+	if err := os.WriteFile(archive, content, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	out := filepath.Join(dir, "out")
+	return out, (&App{}).ExtractArchive(archive, out, options)
+}
+
+// extractedFiles lists the regular files below dir as name=content, sorted by name
+func extractedFiles(t *testing.T, dir string) string {
+	t.Helper()
+
+	var files []string
+	err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil || !d.Type().IsRegular() {
+			return err
+		}
+		b, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		rel, _ := filepath.Rel(dir, path)
+		files = append(files, filepath.ToSlash(rel)+"="+string(b))
+		return nil
+	})
+	if err != nil && !os.IsNotExist(err) {
+		t.Fatal(err)
+	}
+	return strings.Join(files, ",")
+}
+
+func compressTest(t *testing.T, data []byte, newWriter func(w io.Writer) (io.WriteCloser, error)) []byte {
+	t.Helper()
+
+	var buf bytes.Buffer
+	w, err := newWriter(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := w.Write(data); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func TestExtractArchiveFormats(t *testing.T) {
+	tarball := testTar(t, []testArchiveEntry{{name: "root/file.txt", body: "packed\n"}})
+	bz2, _ := base64.StdEncoding.DecodeString(testTarBz2)
+
+	gzipWriter := func(w io.Writer) (io.WriteCloser, error) { return gzip.NewWriter(w), nil }
+	xzWriter := func(w io.Writer) (io.WriteCloser, error) { return xz.NewWriter(w) }
+	zstdWriter := func(w io.Writer) (io.WriteCloser, error) { return zstd.NewWriter(w) }
+
+	single := func(name string) []byte {
+		var buf bytes.Buffer
+		w := gzip.NewWriter(&buf)
+		w.Name = name
+		w.Write([]byte("single\n"))
+		w.Close()
+		return buf.Bytes()
+	}
+
+	// names without a telling extension, the format comes from the content
+	tests := []struct {
+		name    string
+		file    string
+		content []byte
+		want    string
+	}{
+		{"tar", "archive.bin", tarball, "root/file.txt=packed\n"},
+		{"tar.gz", "archive.bin", compressTest(t, tarball, gzipWriter), "root/file.txt=packed\n"},
+		{"tar.xz", "archive.bin", compressTest(t, tarball, xzWriter), "root/file.txt=packed\n"},
+		{"tar.zst", "archive.bin", compressTest(t, tarball, zstdWriter), "root/file.txt=packed\n"},
+		{"tar.bz2", "archive.bin", bz2, "root/file.txt=packed\n"},
+		{"gz named in its header", "download.bin", single("core"), "core=single\n"},
+		{"gz named by the file", "core.gz", single(""), "core=single\n"},
+		{"xz named by the file", "core.xz", compressTest(t, []byte("single\n"), xzWriter), "core=single\n"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			out, result := extractTestArchive(t, tt.file, tt.content, ExtractOptions{})
+			if !result.Flag {
+				t.Fatal(result.Data)
+			}
+			if got := extractedFiles(t, out); got != tt.want {
+				t.Errorf("extracted %q, want %q", got, tt.want)
+			}
+		})
+	}
+
+	t.Run("unsupported", func(t *testing.T) {
+		if _, result := extractTestArchive(t, "archive.tar.gz", []byte("plain text"), ExtractOptions{}); result.Flag || result.Data != "unsupported archive format" {
+			t.Errorf("result = %+v", result)
+		}
+	})
+}
+
+func TestExtractArchiveStripAndInclude(t *testing.T) {
+	tarball := testTar(t, []testArchiveEntry{
+		{name: "app-1.0/bin/core", body: "core"},
+		{name: "app-1.0/bin/helper", body: "helper"},
+		{name: "app-1.0/README.md", body: "readme"},
+		{name: "top.txt", body: "top"},
+	})
+
+	tests := []struct {
+		name    string
+		options ExtractOptions
+		want    string
+	}{
+		{"all", ExtractOptions{}, "app-1.0/README.md=readme,app-1.0/bin/core=core,app-1.0/bin/helper=helper,top.txt=top"},
+		{"strip", ExtractOptions{StripComponents: 1}, "README.md=readme,bin/core=core,bin/helper=helper"},
+		{"strip twice", ExtractOptions{StripComponents: 2}, "core=core,helper=helper"},
+		{"include after strip", ExtractOptions{StripComponents: 1, Include: []string{"bin/core", "*.md"}}, "README.md=readme,bin/core=core"},
+		{"include nested", ExtractOptions{Include: []string{"**/helper"}}, "app-1.0/bin/helper=helper"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			out, result := extractTestArchive(t, "app.tar", tarball, tt.options)
+			if !result.Flag {
+				t.Fatal(result.Data)
+			}
+			if got := extractedFiles(t, out); got != tt.want {
+				t.Errorf("extracted %q, want %q", got, tt.want)
+			}
+		})
+	}
+
+	if _, result := extractTestArchive(t, "app.tar", tarball, ExtractOptions{Include: []string{"["}}); result.Flag {
+		t.Error("accepted an invalid include glob")
+	}
+}
+
+func TestExtractArchiveMaxSize(t *testing.T) {
+	if x, err := newArchiveExtractor(&App{}, t.TempDir(), ExtractOptions{}); err != nil || x.maxSize != 2<<30 {
+		t.Errorf("default limit = %d, %v", x.maxSize, err)
+	}
+
+	entries := []testArchiveEntry{
+		{name: "a.txt", body: strings.Repeat("a", 600)},
+		{name: "b.txt", body: strings.Repeat("b", 600)},
+	}
+
+	for _, format := range []string{"tar", "zip"} {
+		t.Run(format, func(t *testing.T) {
+			var content []byte
+			if format == "tar" {
+				content = testTar(t, entries)
+			} else {
+				path := filepath.Join(t.TempDir(), "sizes.zip")
+				writeTestZip(t, path, entries)
+				content, _ = os.ReadFile(path)
+			}
+
+			// exceeding the limit aborts even without FailFast and leaves no partial file
+			out, result := extractTestArchive(t, "sizes."+format, content, ExtractOptions{MaxSize: 1000})
+			if result.Flag || !strings.Contains(result.Data, errExtractSizeLimit.Error()) {
+				t.Fatalf("result = %+v", result)
+			}
+			if got := extractedFiles(t, out); strings.Contains(got, "b.txt") {
+				t.Errorf("extracted %q", got)
+			}
+
+			for _, maxSize := range []int64{1200, -1} {
+				out, result = extractTestArchive(t, "sizes."+format, content, ExtractOptions{MaxSize: maxSize})
+				if !result.Flag || extractedFiles(t, out) != "a.txt="+entries[0].body+",b.txt="+entries[1].body {
+					t.Errorf("MaxSize %d: %+v", maxSize, result)
+				}
+			}
+		})
+	}
+}
+
+func TestExtractArchiveFailFast(t *testing.T) {
+	tarball := testTar(t, []testArchiveEntry{
+		{name: "first.txt", body: "first"},
+		{name: "../evil.txt", body: "evil"},
+		{name: "middle.txt", body: "middle"},
+		{name: "abs", link: "/etc"},
+		{name: "last.txt", body: "last"},
+	})
+
+	out, result := extractTestArchive(t, "mixed.tar", tarball, ExtractOptions{})
+	if result.Flag || !strings.HasPrefix(result.Data, "2 entries failed to extract:\n") || !strings.Contains(result.Data, "../evil.txt: unsafe entry path") || !strings.Contains(result.Data, "abs: unsafe symlink target: /etc") {
+		t.Errorf("collected = %q", result.Data)
+	}
+	if got := extractedFiles(t, out); got != "first.txt=first,last.txt=last,middle.txt=middle" {
+		t.Errorf("extracted %q", got)
+	}
+
+	out, result = extractTestArchive(t, "mixed.tar", tarball, ExtractOptions{FailFast: true})
+	if result.Flag || result.Data != "../evil.txt: unsafe entry path" {
+		t.Errorf("fail fast = %q", result.Data)
+	}
+	if got := extractedFiles(t, out); got != "first.txt=first" {
+		t.Errorf("extracted %q", got)
+	}
+}
+
+func TestExtractArchiveHardlinks(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("hard links need extra privileges")
+	}
+
+	tarball := testTar(t, []testArchiveEntry{
+		{name: "app/core", body: "core"},
+		{name: "app/core-link", hardlink: "app/core"},
+		{name: "app/escape", hardlink: "../outside.txt"},
+		{name: "app/deep", hardlink: "app/../../outside.txt"},
+		{name: "app/absolute", hardlink: "/etc/passwd"},
+	})
+
+	for _, strip := range []int{0, 1} {
+		dir := useSandbox(t)
+		os.WriteFile(filepath.Join(dir, "outside.txt"), []byte("secret"), 0644)
+		archive := filepath.Join(dir, "links.tar")
+		os.WriteFile(archive, tarball, 0644)
+
+		out := filepath.Join(dir, "out")
+		result := (&App{}).ExtractArchive(archive, out, ExtractOptions{StripComponents: strip})
+		if result.Flag || !strings.Contains(result.Data, "3 entries failed") {
+			t.Errorf("strip %d: result = %q", strip, result.Data)
+		}
+
+		prefix := "app/"
+		if strip == 1 {
+			prefix = ""
+		}
+		if got := extractedFiles(t, out); got != prefix+"core=core,"+prefix+"core-link=core" {
+			t.Errorf("strip %d: extracted %q", strip, got)
+		}
+	}
+}
+
+func TestExtractArchiveModes(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("no unix permissions")
+	}
+
+	tarball := testTar(t, []testArchiveEntry{
+		{name: "core", body: "core", mode: 0755},
+		{name: "secret", body: "secret", mode: 0600},
+		{name: "plain", body: "plain"},
+	})
+
+	dir := useSandbox(t)
+	out := filepath.Join(dir, "out")
+	os.MkdirAll(out, os.ModePerm)
+	// an existing file gets the permissions from the archive too
+	os.WriteFile(filepath.Join(out, "secret"), []byte("old"), 0666)
+
+	archive := filepath.Join(dir, "modes.tar")
+	os.WriteFile(archive, tarball, 0644)
+	if result := (&App{}).ExtractArchive(archive, out, ExtractOptions{}); !result.Flag {
+		t.Fatal(result.Data)
+	}
+
+	for name, want := range map[string]fs.FileMode{"core": 0755, "secret": 0600, "plain": 0644} {
+		stat, err := os.Stat(filepath.Join(out, name))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if stat.Mode().Perm() != want {
+			t.Errorf("%s = %v, want %v", name, stat.Mode().Perm(), want)
+		}
+	}
+}
@@ -74,6 +74,8 @@ func CreateApp(fs embed.FS) *App {
 
 	loadConfig()
 
+	go purgeTrash()
+
 	return app
 }
 
@@ -87,6 +89,7 @@ func (a *App) IsStartup() bool {
 
 func (a *App) ExitApp() {
 	log.Printf("ExitApp")
+	stopAllProcesses(5)
 	Env.PreventExit = false
 	runtime.Quit(a.Ctx)
 }
@@ -303,4 +306,6 @@ func loadConfig() {
 	if !Env.FromTaskSch {
 		Config.WindowStartState = int(options.Normal)
 	}
+
+	initSandbox()
 }
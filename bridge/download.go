@@ -0,0 +1,326 @@
+package bridge
+
+import (
+	"encoding/json"
+	"fmt"
+	"hash"
+	"io"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// partialDownload tracks path.part and the validators of the response it was written
+// from, kept in path.part.json so a later attempt can ask for the remaining bytes only
+type partialDownload struct {
+	path      string
+	partPath  string
+	statePath string
+	url       string
+}
+
+type partialDownloadState struct {
+	URL          string `json:"url"`
+	ETag         string `json:"etag,omitempty"`
+	LastModified string `json:"lastModified,omitempty"`
+}
+
+func newPartialDownload(path string, url string) *partialDownload {
+	return &partialDownload{
+		path:      path,
+		partPath:  path + ".part",
+		statePath: path + ".part.json",
+		url:       url,
+	}
+}
+
+// request sends the request built by newRequest, asking only for the bytes missing from
+// the partial file when there is one to resume. It returns the response and the offset
+// the response body starts at, which is 0 unless the server agreed to resume.
+func (p *partialDownload) request(client *http.Client, newRequest func() (*http.Request, error)) (*http.Response, int64, error) {
+	req, err := newRequest()
+	if err != nil {
+		return nil, 0, err
+	}
+
+	offset, validator := p.resumePoint(req)
+	if offset == 0 {
+		resp, err := client.Do(req)
+		return resp, 0, err
+	}
+
+	req.Header.Set("Range", fmt.Sprintf("bytes=%d-", offset))
+	req.Header.Set("If-Range", validator)
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	if resp.StatusCode == http.StatusPartialContent && contentRangeStart(resp) == offset {
+		return resp, offset, nil
+	}
+
+	// the partial file no longer matches what the server has, or the server sent some other
+	// range that cannot be appended to it, so start over
+	if resp.StatusCode == http.StatusRequestedRangeNotSatisfiable || resp.StatusCode == http.StatusPartialContent {
+		resp.Body.Close()
+		p.discard()
+
+		req, err := newRequest()
+		if err != nil {
+			return nil, 0, err
+		}
+		resp, err := client.Do(req)
+		return resp, 0, err
+	}
+
+	return resp, 0, nil
+}
+
+// resumePoint returns the size of the partial file and the validator to send in If-Range,
+// or 0 when the download cannot be resumed
+func (p *partialDownload) resumePoint(req *http.Request) (int64, string) {
+	if req.Method != http.MethodGet || req.Header.Get("Range") != "" {
+		return 0, ""
+	}
+
+	stat, err := os.Stat(p.partPath)
+	if err != nil || stat.Size() == 0 {
+		return 0, ""
+	}
+
+	b, err := os.ReadFile(p.statePath)
+	if err != nil {
+		return 0, ""
+	}
+
+	var state partialDownloadState
+	if err := json.Unmarshal(b, &state); err != nil || state.URL != p.url {
+		return 0, ""
+	}
+
+	// weak entity tags are not allowed in If-Range
+	if state.ETag != "" && !strings.HasPrefix(state.ETag, "W/") {
+		return stat.Size(), state.ETag
+	}
+	if state.LastModified != "" {
+		return stat.Size(), state.LastModified
+	}
+
+	return 0, ""
+}
+
+// open opens the partial file for the response body, appending when resuming at offset
+// and truncating otherwise. The validators of successful responses are saved so the
+// download can be resumed if it is interrupted.
+func (p *partialDownload) open(resp *http.Response, offset int64) (*os.File, error) {
+	flag := os.O_WRONLY | os.O_CREATE | os.O_TRUNC
+	if offset > 0 {
+		flag = os.O_WRONLY | os.O_APPEND
+	}
+
+	file, err := os.OpenFile(p.partPath, flag, 0644)
+	if err != nil {
+		return nil, err
+	}
+
+	state := partialDownloadState{
+		URL:          p.url,
+		ETag:         resp.Header.Get("ETag"),
+		LastModified: resp.Header.Get("Last-Modified"),
+	}
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 && (state.ETag != "" || state.LastModified != "") {
+		if b, err := json.Marshal(state); err == nil {
+			_ = os.WriteFile(p.statePath, b, 0644)
+		}
+	} else {
+		_ = os.Remove(p.statePath)
+	}
+
+	return file, nil
+}
+
+// hashExisting feeds the bytes already in the partial file into h
+func (p *partialDownload) hashExisting(h hash.Hash) error {
+	file, err := os.Open(p.partPath)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	_, err = io.Copy(h, file)
+	return err
+}
+
+// commit moves the completed partial file into place
+func (p *partialDownload) commit() error {
+	if err := os.Rename(p.partPath, p.path); err != nil {
+		return err
+	}
+	_ = os.Remove(p.statePath)
+	return nil
+}
+
+func (p *partialDownload) discard() {
+	_ = os.Remove(p.partPath)
+	_ = os.Remove(p.statePath)
+}
+
+// contentRangeStart returns the first byte position of a "bytes start-end/size"
+// Content-Range header, or -1 when it is missing or malformed
+func contentRangeStart(resp *http.Response) int64 {
+	value, ok := strings.CutPrefix(resp.Header.Get("Content-Range"), "bytes ")
+	if !ok {
+		return -1
+	}
+	start, _, ok := strings.Cut(value, "-")
+	if !ok {
+		return -1
+	}
+	n, err := strconv.ParseInt(start, 10, 64)
+	if err != nil {
+		return -1
+	}
+	return n
+}
+
+const (
+	maxDownloadSegments    = 16
+	minDownloadSegmentSize = 1024 * 1024
+)
+
+type byteRange struct {
+	start int64
+	end   int64 // inclusive
+}
+
+// segmentRanges splits the body of a complete response into at most segments ranges of
+// at least minDownloadSegmentSize bytes. It returns nil unless the response is the whole
+// resource, its size is known and the server advertises byte ranges.
+func segmentRanges(resp *http.Response, offset int64, segments int) []byteRange {
+	if segments < 2 || offset != 0 || resp.StatusCode != http.StatusOK || resp.ContentLength <= 0 {
+		return nil
+	}
+	if resp.Request == nil || resp.Request.Method != http.MethodGet || resp.Request.Header.Get("Range") != "" {
+		return nil
+	}
+	if !strings.EqualFold(strings.TrimSpace(resp.Header.Get("Accept-Ranges")), "bytes") {
+		return nil
+	}
+
+	size := resp.ContentLength
+	segments = min(segments, maxDownloadSegments, int(size/minDownloadSegmentSize))
+	if segments < 2 {
+		return nil
+	}
+
+	ranges := make([]byteRange, segments)
+	step := size / int64(segments)
+	for i := range ranges {
+		ranges[i] = byteRange{int64(i) * step, int64(i+1)*step - 1}
+	}
+	ranges[segments-1].end = size - 1
+
+	return ranges
+}
+
+// fetchSegments fills the partial file with ranges fetched concurrently. The first range
+// is read from resp, which already carries the start of the body, and every other range
+// is requested separately. Any failure calls cancel so the remaining requests stop.
+func (p *partialDownload) fetchSegments(client *http.Client, newRequest func() (*http.Request, error), resp *http.Response, ranges []byteRange, progress io.Writer, cancel func()) error {
+	file, err := os.OpenFile(p.partPath, os.O_RDWR|os.O_CREATE|os.O_TRUNC, 0644)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	// out of order writes leave holes, so the file cannot be resumed by its size
+	_ = os.Remove(p.statePath)
+
+	if err := file.Truncate(resp.ContentLength); err != nil {
+		return err
+	}
+
+	validator := resp.Header.Get("ETag")
+	if validator == "" || strings.HasPrefix(validator, "W/") {
+		validator = resp.Header.Get("Last-Modified")
+	}
+
+	var (
+		wg       sync.WaitGroup
+		errOnce  sync.Once
+		firstErr error
+	)
+
+	fail := func(err error) {
+		errOnce.Do(func() {
+			firstErr = err
+			cancel()
+		})
+	}
+
+	for i, r := range ranges {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			body := resp.Body
+			if i > 0 {
+				segment, err := fetchSegment(client, newRequest, r, validator)
+				if err != nil {
+					fail(err)
+					return
+				}
+				defer segment.Close()
+				body = segment
+			}
+
+			length := r.end - r.start + 1
+			writer := io.NewOffsetWriter(file, r.start)
+			n, err := io.Copy(writer, io.TeeReader(io.LimitReader(body, length), progress))
+			if err == nil && n != length {
+				err = io.ErrUnexpectedEOF
+			}
+			if err != nil {
+				fail(fmt.Errorf("segment %d-%d: %w", r.start, r.end, err))
+			}
+		}()
+	}
+
+	wg.Wait()
+
+	if firstErr != nil {
+		return firstErr
+	}
+
+	return file.Sync()
+}
+
+// fetchSegment requests the bytes of r, refusing any response that is not exactly that
+// range of the same version of the resource
+func fetchSegment(client *http.Client, newRequest func() (*http.Request, error), r byteRange, validator string) (io.ReadCloser, error) {
+	req, err := newRequest()
+	if err != nil {
+		return nil, err
+	}
+
+	req.Header.Set("Range", fmt.Sprintf("bytes=%d-%d", r.start, r.end))
+	if validator != "" {
+		req.Header.Set("If-Range", validator)
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.StatusCode != http.StatusPartialContent || contentRangeStart(resp) != r.start {
+		resp.Body.Close()
+		return nil, fmt.Errorf("segment %d-%d: unexpected response %s", r.start, r.end, resp.Status)
+	}
+
+	return resp.Body, nil
+}
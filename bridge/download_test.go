@@ -0,0 +1,203 @@
+package bridge
+
+import (
+	"bytes"
+	"crypto/rand"
+	"crypto/sha256"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// rangeServer serves content with an ETag and records the Range header of every request.
+// handle may answer a request itself and return true.
+type rangeServer struct {
+	content []byte
+	etag    string
+	handle  func(w http.ResponseWriter, r *http.Request) bool
+
+	mu     sync.Mutex
+	ranges []string
+}
+
+func (s *rangeServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	s.ranges = append(s.ranges, r.Header.Get("Range"))
+	s.mu.Unlock()
+
+	if s.handle != nil && s.handle(w, r) {
+		return
+	}
+
+	w.Header().Set("ETag", s.etag)
+	http.ServeContent(w, r, "", time.Time{}, bytes.NewReader(s.content))
+}
+
+func (s *rangeServer) requestedRanges() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.ranges
+}
+
+func newRangeServer(t *testing.T, content []byte) (*rangeServer, *httptest.Server) {
+	s := &rangeServer{content: content, etag: `"v1"`}
+	server := httptest.NewServer(s)
+	t.Cleanup(server.Close)
+	return s, server
+}
+
+// writePartial leaves a partial download of the first n bytes of content behind
+func writePartial(t *testing.T, path string, url string, content []byte, n int, etag string) {
+	t.Helper()
+	os.MkdirAll(filepath.Dir(path), os.ModePerm)
+	if err := os.WriteFile(path+".part", content[:n], 0644); err != nil {
+		t.Fatal(err)
+	}
+	state := fmt.Sprintf(`{"url":%q,"etag":%q}`, url, etag)
+	if err := os.WriteFile(path+".part.json", []byte(state), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func checkDownloaded(t *testing.T, path string, content []byte) {
+	t.Helper()
+	b, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(b, content) {
+		t.Fatalf("downloaded %d bytes, want the %d bytes served", len(b), len(content))
+	}
+	for _, leftover := range []string{path + ".part", path + ".part.json"} {
+		if _, err := os.Stat(leftover); !os.IsNotExist(err) {
+			t.Errorf("%s left behind", filepath.Base(leftover))
+		}
+	}
+}
+
+func checksumOption(content []byte) string {
+	return fmt.Sprintf("sha256:%x", sha256.Sum256(content))
+}
+
+func TestDownloadResume(t *testing.T) {
+	dir := useSandbox(t)
+	content := []byte(strings.Repeat("0123456789", 10))
+	s, server := newRangeServer(t, content)
+
+	path := filepath.Join(dir, "data", "file.bin")
+	writePartial(t, path, server.URL, content, 40, s.etag)
+
+	result := (&App{}).Download(http.MethodGet, server.URL, path, nil, "", RequestOptions{Checksum: checksumOption(content)})
+	if !result.Flag {
+		t.Fatal(result.Body)
+	}
+	if result.Status != http.StatusPartialContent {
+		t.Errorf("status = %d, want 206", result.Status)
+	}
+	if got := s.requestedRanges(); len(got) != 1 || got[0] != "bytes=40-" {
+		t.Errorf("ranges = %q", got)
+	}
+	checkDownloaded(t, path, content)
+}
+
+func TestDownloadResumeChangedResource(t *testing.T) {
+	dir := useSandbox(t)
+	content := []byte(strings.Repeat("abcdefghij", 10))
+	_, server := newRangeServer(t, content)
+
+	// the partial file belongs to an older version, If-Range makes the server send it all
+	path := filepath.Join(dir, "data", "file.bin")
+	writePartial(t, path, server.URL, []byte(strings.Repeat("x", 100)), 40, `"v0"`)
+
+	result := (&App{}).Download(http.MethodGet, server.URL, path, nil, "", RequestOptions{})
+	if !result.Flag || result.Status != http.StatusOK {
+		t.Fatalf("result = %+v", result)
+	}
+	checkDownloaded(t, path, content)
+}
+
+func TestDownloadResumeMismatchedRange(t *testing.T) {
+	dir := useSandbox(t)
+	content := []byte(strings.Repeat("0123456789", 10))
+	s, server := newRangeServer(t, content)
+
+	// answers any range with the whole body as a 206 starting at 0
+	s.handle = func(w http.ResponseWriter, r *http.Request) bool {
+		if r.Header.Get("Range") == "" {
+			return false
+		}
+		w.Header().Set("Content-Range", fmt.Sprintf("bytes 0-%d/%d", len(content)-1, len(content)))
+		w.WriteHeader(http.StatusPartialContent)
+		w.Write(content)
+		return true
+	}
+
+	path := filepath.Join(dir, "data", "file.bin")
+	writePartial(t, path, server.URL, content, 40, s.etag)
+
+	result := (&App{}).Download(http.MethodGet, server.URL, path, nil, "", RequestOptions{Checksum: checksumOption(content)})
+	if !result.Flag || result.Status != http.StatusOK {
+		t.Fatalf("result = %+v", result)
+	}
+	if got := s.requestedRanges(); len(got) != 2 || got[0] != "bytes=40-" || got[1] != "" {
+		t.Errorf("ranges = %q", got)
+	}
+	checkDownloaded(t, path, content)
+}
+
+func TestDownloadSegments(t *testing.T) {
+	dir := useSandbox(t)
+	content := make([]byte, 3*minDownloadSegmentSize+123)
+	rand.Read(content)
+	s, server := newRangeServer(t, content)
+
+	path := filepath.Join(dir, "data", "file.bin")
+	result := (&App{}).Download(http.MethodGet, server.URL, path, nil, "", RequestOptions{Segments: 3, Checksum: checksumOption(content)})
+	if !result.Flag {
+		t.Fatal(result.Body)
+	}
+	checkDownloaded(t, path, content)
+
+	want := []string{
+		"",
+		fmt.Sprintf("bytes=%d-%d", minDownloadSegmentSize+41, 2*minDownloadSegmentSize+81),
+		fmt.Sprintf("bytes=%d-%d", 2*minDownloadSegmentSize+82, len(content)-1),
+	}
+	got := s.requestedRanges()
+	if len(got) != 3 || got[0] != want[0] || !strings.Contains(strings.Join(got, ","), want[1]) || !strings.Contains(strings.Join(got, ","), want[2]) {
+		t.Errorf("ranges = %q, want %q", got, want)
+	}
+}
+
+func TestDownloadSegmentFailure(t *testing.T) {
+	dir := useSandbox(t)
+	content := make([]byte, 2*minDownloadSegmentSize)
+	rand.Read(content)
+	s, server := newRangeServer(t, content)
+
+	// advertises ranges but then ignores them
+	s.handle = func(w http.ResponseWriter, r *http.Request) bool {
+		if r.Header.Get("Range") == "" {
+			return false
+		}
+		w.Write(content)
+		return true
+	}
+
+	path := filepath.Join(dir, "data", "file.bin")
+	result := (&App{}).Download(http.MethodGet, server.URL, path, nil, "", RequestOptions{Segments: 2})
+	if result.Flag {
+		t.Fatal("expected the ignored range to fail the download")
+	}
+	for _, leftover := range []string{path, path + ".part", path + ".part.json"} {
+		if _, err := os.Stat(leftover); !os.IsNotExist(err) {
+			t.Errorf("%s left behind", filepath.Base(leftover))
+		}
+	}
+}
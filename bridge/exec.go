@@ -2,6 +2,7 @@ package bridge
 
 import (
 	"bufio"
+	"bytes"
 	"context"
 	"errors"
 	"fmt"
@@ -14,6 +15,7 @@ import (
 	"slices"
 	"strconv"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/shirou/gopsutil/v3/process"
@@ -23,128 +25,71 @@ import (
 func (a *App) Exec(path string, args []string, options ExecOptions) FlagResult {
 	log.Printf("Exec: %s %s %v", path, args, options)
 
-	exePath := resolvePath(path)
-
-	if _, err := os.Stat(exePath); os.IsNotExist(err) {
-		exePath = path
-	}
-
-	cmd := exec.Command(exePath, args...)
-	SetCmdWindowHidden(cmd)
-
-	if options.WorkingDirectory != "" {
-		cmd.Dir = resolvePath(options.WorkingDirectory)
-	}
-	cmd.Env = os.Environ()
-
-	for key, value := range options.Env {
-		cmd.Env = append(cmd.Env, key+"="+value)
-	}
+	var out bytes.Buffer
 
-	out, err := cmd.CombinedOutput()
+	_, err := runCommand(a, path, args, options, &out, &out)
 
-	output := strings.TrimSpace(DecodeCommandOutput(out))
+	output := strings.TrimSpace(DecodeCommandOutput(out.Bytes()))
 
 	if err != nil {
-		if output == "" {
-			output = err.Error()
+		// the reason, a timeout, a cancel or the exit status with its code, leads the output
+		if output != "" {
+			return FlagResult{false, err.Error() + ": " + output}
 		}
-		return FlagResult{false, output}
+		return FlagResult{false, err.Error()}
 	}
 
 	return FlagResult{true, output}
 }
 
-func (a *App) ExecBackground(path string, args []string, outEvent string, endEvent string, options ExecOptions) FlagResult {
-	log.Printf("ExecBackground: %s %s %s %s %v", path, args, outEvent, endEvent, options)
+// execInterruptedError is returned by runCommand for a command stopped by its timeout or
+// a cancel event rather than exiting on its own
+type execInterruptedError struct {
+	reason string
+}
 
-	exePath := resolvePath(path)
-	pidPath := ""
-	logPath := ""
+func (e *execInterruptedError) Error() string {
+	return e.reason
+}
 
-	if _, err := os.Stat(exePath); os.IsNotExist(err) {
-		exePath = path
-	}
+func (a *App) ExecWithResult(path string, args []string, options ExecOptions) ExecResult {
+	log.Printf("ExecWithResult: %s %s %v", path, args, options)
 
-	if options.PidFile != "" {
-		pidPath = resolvePath(options.PidFile)
-	}
+	var stdout, stderr bytes.Buffer
 
-	done := make(chan struct{})
-	outputDone := make(chan struct{})
-	cmd := exec.Command(exePath, args...)
-	SetCmdWindowHidden(cmd)
+	start := time.Now()
+	state, err := runCommand(a, path, args, options, &stdout, &stderr)
 
-	if options.WorkingDirectory != "" {
-		cmd.Dir = resolvePath(options.WorkingDirectory)
+	result := ExecResult{
+		Flag:     err == nil,
+		Stdout:   strings.TrimSpace(DecodeCommandOutput(stdout.Bytes())),
+		Stderr:   strings.TrimSpace(DecodeCommandOutput(stderr.Bytes())),
+		ExitCode: -1,
+		Duration: time.Since(start).Milliseconds(),
 	}
-	cmd.Env = os.Environ()
-
-	for key, value := range options.Env {
-		cmd.Env = append(cmd.Env, key+"="+value)
-	}
-
-	var stdout io.ReadCloser
-	var err error
-	var logFile *os.File
-
-	switch {
-	case options.LogFile != "":
-		logPath = resolvePath(options.LogFile)
-		if err := os.MkdirAll(filepath.Dir(logPath), os.ModePerm); err != nil {
-			return FlagResult{false, err.Error()}
-		}
-
-		logFile, err = os.OpenFile(logPath, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0644)
-		if err != nil {
-			return FlagResult{false, err.Error()}
-		}
-		defer logFile.Close()
 
-		cmd.Stdout = logFile
-		cmd.Stderr = logFile
-
-	case outEvent != "":
-		stdout, err = cmd.StdoutPipe()
-		if err != nil {
-			return FlagResult{false, err.Error()}
-		}
-		cmd.Stderr = cmd.Stdout
+	if state != nil {
+		result.ExitCode = state.ExitCode()
+		result.Signal = ProcessStateSignal(state)
 	}
-
-	if err := cmd.Start(); err != nil {
-		return FlagResult{false, err.Error()}
+	if err != nil {
+		result.Error = err.Error()
 	}
 
-	pid := strconv.Itoa(cmd.Process.Pid)
+	return result
+}
 
-	if pidPath != "" {
-		if err := os.WriteFile(pidPath, []byte(pid), os.ModePerm); err != nil {
-			_ = SendExitSignal(cmd.Process)
-			_ = waitForProcessExitWithTimeout(cmd.Process, 10)
-			_ = cmd.Wait()
-			return FlagResult{false, err.Error()}
-		}
-	}
+func (a *App) ExecBackground(path string, args []string, outEvent string, endEvent string, options ExecOptions) FlagResult {
+	log.Printf("ExecBackground: %s %s %s %s %v", path, args, outEvent, endEvent, options)
 
-	if outEvent != "" {
-		if logPath != "" {
-			go tailAndEmitLogFile(a, logPath, outEvent, options, done, outputDone)
-		} else {
-			go scanAndEmitOutput(a, stdout, outEvent, options, outputDone)
-		}
-	} else {
-		close(outputDone)
+	proc, err := startBackgroundProcess(a, path, args, outEvent, options)
+	if err != nil {
+		return FlagResult{false, err.Error()}
 	}
 
 	go func() {
-		err := cmd.Wait()
-		close(done)
-		<-outputDone
+		err := proc.wait()
 
-		if pidPath != "" {
-			_ = os.Remove(pidPath)
-		}
 		if endEvent != "" {
 			if err != nil {
 				runtime.EventsEmit(a.Ctx, endEvent, err.Error())
@@ -154,7 +99,7 @@ func (a *App) ExecBackground(path string, args []string, outEvent string, endEve
 		}
 	}()
 
-	return FlagResult{true, pid}
+	return FlagResult{true, strconv.Itoa(proc.cmd.Process.Pid)}
 }
 
 func (a *App) ProcessInfo(pid int32) FlagResult {
@@ -241,6 +186,237 @@ func (a *App) KillProcess(pid int, timeout int) FlagResult {
 	return FlagResult{true, "Success"}
 }
 
+type backgroundProcess struct {
+	cmd        *exec.Cmd
+	entry      *processEntry
+	logWriter  *rotatingLogWriter
+	stdout     *io.PipeWriter
+	pidPath    string
+	done       chan struct{}
+	outputDone chan struct{}
+}
+
+func newCommand(ctx context.Context, path string, args []string, options ExecOptions) *exec.Cmd {
+	exePath := resolvePath(path)
+
+	if _, err := os.Stat(exePath); os.IsNotExist(err) {
+		exePath = path
+	}
+
+	cmd := exec.CommandContext(ctx, exePath, args...)
+	SetCmdWindowHidden(cmd)
+
+	if options.WorkingDirectory != "" {
+		cmd.Dir = resolvePath(options.WorkingDirectory)
+	}
+	cmd.Env = os.Environ()
+
+	for key, value := range options.Env {
+		cmd.Env = append(cmd.Env, key+"="+value)
+	}
+
+	return cmd
+}
+
+func startBackgroundProcess(a *App, path string, args []string, outEvent string, options ExecOptions) (*backgroundProcess, error) {
+	pidPath := ""
+	logPath := ""
+
+	if options.PidFile != "" {
+		pidPath = resolvePath(options.PidFile)
+	}
+
+	done := make(chan struct{})
+	outputDone := make(chan struct{})
+	cmd := newCommand(context.Background(), path, args, options)
+
+	// reserve the id before the log is touched, a duplicate must not truncate or rotate
+	// the log of the process already running under it
+	entry, err := registerProcess(cmd, true, options)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		if cmd.Process == nil {
+			entry.unregister()
+		}
+	}()
+
+	var stdout *io.PipeReader
+	var stdoutWriter *io.PipeWriter
+	var logFile *os.File
+	var logWriter *rotatingLogWriter
+
+	started := false
+	defer func() {
+		if !started && logWriter != nil {
+			logWriter.Close()
+		}
+	}()
+
+	switch {
+	case options.LogFile != "":
+		logPath = resolvePath(options.LogFile)
+		if err := os.MkdirAll(filepath.Dir(logPath), os.ModePerm); err != nil {
+			return nil, err
+		}
+
+		if useLogRotation(options) {
+			logWriter, err = openRotatingLogWriter(logPath, options)
+			if err != nil {
+				return nil, err
+			}
+
+			cmd.Stdout = logWriter
+			cmd.Stderr = logWriter
+			break
+		}
+
+		logFile, err = os.OpenFile(logPath, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0644)
+		if err != nil {
+			return nil, err
+		}
+		defer logFile.Close()
+
+		cmd.Stdout = logFile
+		cmd.Stderr = logFile
+
+	case outEvent != "" || options.OutputBufferLines > 0:
+		// unlike StdoutPipe, Wait returns only after all output was copied into the pipe,
+		// so the last lines of a process that exits right away are not lost
+		stdout, stdoutWriter = io.Pipe()
+		cmd.Stdout = stdoutWriter
+		cmd.Stderr = stdoutWriter
+		cmd.WaitDelay = 2 * time.Second
+	}
+
+	if options.Stdin {
+		entry.stdin, err = cmd.StdinPipe()
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if err := cmd.Start(); err != nil {
+		return nil, err
+	}
+
+	entry.started(cmd.Process)
+
+	pid := strconv.Itoa(cmd.Process.Pid)
+
+	if pidPath != "" {
+		if err := os.WriteFile(pidPath, []byte(pid), os.ModePerm); err != nil {
+			_ = SendExitSignal(cmd.Process)
+			_ = waitForProcessExitWithTimeout(cmd.Process, 10)
+			waitErr := cmd.Wait()
+			if stdoutWriter != nil {
+				stdoutWriter.Close()
+			}
+			entry.exited(cmd.ProcessState, waitErr)
+			return nil, err
+		}
+	}
+
+	if outEvent != "" || entry.output != nil {
+		if logPath != "" {
+			go tailAndEmitLogFile(a, logPath, logWriter, outEvent, entry.output, options, done, outputDone)
+		} else {
+			go scanAndEmitOutput(a, stdout, outEvent, entry.output, options, outputDone)
+		}
+	} else {
+		close(outputDone)
+	}
+
+	started = true
+
+	return &backgroundProcess{
+		cmd:        cmd,
+		entry:      entry,
+		logWriter:  logWriter,
+		stdout:     stdoutWriter,
+		pidPath:    pidPath,
+		done:       done,
+		outputDone: outputDone,
+	}, nil
+}
+
+// runCommand runs a command to completion, the returned error distinguishes
+// a timeout, a cancellation and a non-zero exit
+func runCommand(a *App, path string, args []string, options ExecOptions, stdout, stderr io.Writer) (*os.ProcessState, error) {
+	ctx := context.Background()
+	var cancel context.CancelFunc
+	if options.Timeout > 0 {
+		ctx, cancel = context.WithTimeout(ctx, time.Duration(options.Timeout)*time.Second)
+	} else {
+		ctx, cancel = context.WithCancel(ctx)
+	}
+	defer cancel()
+
+	if options.CancelId != "" {
+		runtime.EventsOn(a.Ctx, options.CancelId, func(data ...any) {
+			log.Printf("Exec Canceled: %s %s", path, args)
+			cancel()
+		})
+		defer runtime.EventsOff(a.Ctx, options.CancelId)
+	}
+
+	cmd := newCommand(ctx, path, args, options)
+	cmd.Cancel = func() error {
+		return KillProcessTree(cmd.Process)
+	}
+	cmd.WaitDelay = 2 * time.Second
+	SetCmdProcessGroup(cmd)
+
+	cmd.Stdout = stdout
+	cmd.Stderr = stderr
+
+	entry, err := registerProcess(cmd, false, options)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := cmd.Start(); err != nil {
+		entry.unregister()
+		return nil, err
+	}
+
+	entry.started(cmd.Process)
+	err = cmd.Wait()
+
+	switch {
+	case err == nil:
+	case errors.Is(ctx.Err(), context.DeadlineExceeded):
+		err = &execInterruptedError{fmt.Sprintf("timed out after %d seconds", options.Timeout)}
+	case errors.Is(ctx.Err(), context.Canceled):
+		err = &execInterruptedError{"cancelled"}
+	}
+
+	entry.exited(cmd.ProcessState, err)
+
+	return cmd.ProcessState, err
+}
+
+func (p *backgroundProcess) wait() error {
+	err := p.cmd.Wait()
+	if p.logWriter != nil {
+		p.logWriter.Close()
+	}
+	if p.stdout != nil {
+		p.stdout.Close()
+	}
+	close(p.done)
+	<-p.outputDone
+
+	if p.pidPath != "" {
+		_ = os.Remove(p.pidPath)
+	}
+
+	p.entry.exited(p.cmd.ProcessState, err)
+
+	return err
+}
+
 func waitForProcessExitWithTimeout(process *os.Process, timeoutSeconds int) error {
 	ctx, cancel := context.WithTimeout(context.Background(), time.Duration(timeoutSeconds)*time.Second)
 	defer cancel()
@@ -294,9 +470,12 @@ func getDarwinProcessRSS(pid int32) (uint64, error) {
 	return rss * 1024, nil
 }
 
-func scanAndEmitOutput(a *App, reader io.Reader, outEvent string, options ExecOptions, outputDone chan<- struct{}) {
+func scanAndEmitOutput(a *App, reader io.Reader, outEvent string, output *outputRing, options ExecOptions, outputDone chan<- struct{}) {
 	defer close(outputDone)
 
+	emitter := newOutputEmitter(a, outEvent, output, options)
+	defer emitter.close()
+
 	scanner := bufio.NewScanner(reader)
 	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
 	stopOutput := false
@@ -305,10 +484,12 @@ func scanAndEmitOutput(a *App, reader io.Reader, outEvent string, options ExecOp
 		text := DecodeCommandOutput(scanner.Bytes())
 
 		if !stopOutput {
-			runtime.EventsEmit(a.Ctx, outEvent, text)
+			emitter.emit(text)
 
 			if options.StopOutputKeyword != "" && strings.Contains(text, options.StopOutputKeyword) {
-				stopOutput = true
+				// the output ring keeps recording after the event stream stops
+				emitter.stopEvents()
+				stopOutput = output == nil
 			}
 		}
 	}
@@ -316,11 +497,17 @@ func scanAndEmitOutput(a *App, reader io.Reader, outEvent string, options ExecOp
 	_ = scanner.Err()
 }
 
-func tailAndEmitLogFile(a *App, path string, outEvent string, options ExecOptions, done <-chan struct{}, outputDone chan<- struct{}) {
+// tailAndEmitLogFile follows the log file at path. When the file is written by logWriter,
+// each segment is read to the end before it is rotated away.
+func tailAndEmitLogFile(a *App, path string, logWriter *rotatingLogWriter, outEvent string, output *outputRing, options ExecOptions, done <-chan struct{}, outputDone chan<- struct{}) {
 	defer close(outputDone)
 
 	offset := int64(0)
 	pending := ""
+	var current os.FileInfo
+	emitter := newOutputEmitter(a, outEvent, output, options)
+	defer emitter.close()
+
 	ticker := time.NewTicker(100 * time.Millisecond)
 	defer ticker.Stop()
 
@@ -332,12 +519,54 @@ func tailAndEmitLogFile(a *App, path string, outEvent string, options ExecOption
 			return false
 		}
 
-		runtime.EventsEmit(a.Ctx, outEvent, text)
-		return options.StopOutputKeyword != "" && strings.Contains(text, options.StopOutputKeyword)
+		emitter.emit(text)
+
+		if options.StopOutputKeyword != "" && strings.Contains(text, options.StopOutputKeyword) {
+			// the output ring keeps recording after the event stream stops
+			emitter.stopEvents()
+			return output == nil
+		}
+
+		return false
+	}
+
+	emitLines := func(data []byte) bool {
+		chunk := pending + string(data)
+		lines := strings.Split(chunk, "\n")
+		pending = lines[len(lines)-1]
+
+		if slices.ContainsFunc(lines[:len(lines)-1], emitLine) {
+			pending = ""
+			return true
+		}
+
+		return false
+	}
+
+	// drainRotated emits what is left of the previous segment after the log file was rotated
+	drainRotated := func() bool {
+		rotatedPath := path + ".1"
+		stat, err := os.Stat(rotatedPath)
+		if err != nil || !os.SameFile(current, stat) {
+			return false
+		}
+
+		data, _, _, err := readFileRange(rotatedPath, offset)
+		if err != nil || len(data) == 0 {
+			return false
+		}
+
+		return emitLines(data)
 	}
 
 	readNewContent := func(flush bool) bool {
-		data, nextOffset, err := readFileRange(path, offset)
+		data, nextOffset, stat, err := readFileRange(path, offset)
+		if err == nil && current != nil && !os.SameFile(current, stat) {
+			if drainRotated() {
+				return true
+			}
+			data, nextOffset, stat, err = readFileRange(path, 0)
+		}
 		if err != nil {
 			if !errors.Is(err, os.ErrNotExist) {
 				log.Printf("Failed to read log file %s: %v", path, err)
@@ -345,6 +574,8 @@ func tailAndEmitLogFile(a *App, path string, outEvent string, options ExecOption
 			return false
 		}
 
+		current = stat
+
 		if len(data) == 0 {
 			if flush && pending != "" {
 				return emitLine(pending)
@@ -354,12 +585,8 @@ func tailAndEmitLogFile(a *App, path string, outEvent string, options ExecOption
 		}
 
 		offset = nextOffset
-		chunk := pending + string(data)
-		lines := strings.Split(chunk, "\n")
-		pending = lines[len(lines)-1]
 
-		if slices.ContainsFunc(lines[:len(lines)-1], emitLine) {
-			pending = ""
+		if emitLines(data) {
 			return true
 		}
 
@@ -374,44 +601,69 @@ func tailAndEmitLogFile(a *App, path string, outEvent string, options ExecOption
 		return false
 	}
 
+	var mu sync.Mutex
+	stopped := false
+
+	if logWriter != nil {
+		logWriter.onRotate(func() {
+			mu.Lock()
+			defer mu.Unlock()
+
+			if !stopped {
+				stopped = readNewContent(false)
+			}
+		})
+		defer logWriter.onRotate(nil)
+	}
+
 	for {
 		select {
 		case <-done:
-			_ = readNewContent(true)
+			mu.Lock()
+			if !stopped {
+				_ = readNewContent(true)
+			}
+			mu.Unlock()
 			return
 		case <-ticker.C:
-			if readNewContent(false) {
+			mu.Lock()
+			if !stopped {
+				stopped = readNewContent(false)
+			}
+			mu.Unlock()
+			if stopped {
 				return
 			}
 		}
 	}
 }
 
-func readFileRange(path string, offset int64) ([]byte, int64, error) {
+func readFileRange(path string, offset int64) ([]byte, int64, os.FileInfo, error) {
 	file, err := os.Open(path)
 	if err != nil {
-		return nil, offset, err
+		return nil, offset, nil, err
 	}
 	defer file.Close()
 
 	stat, err := file.Stat()
 	if err != nil {
-		return nil, offset, err
+		return nil, offset, nil, err
 	}
 
 	size := stat.Size()
 	if offset > size {
-		offset = size
+		// the file was truncated, start over
+		offset = 0
 	}
 	if size == offset {
-		return nil, offset, nil
+		return nil, offset, stat, nil
 	}
 
 	buf := make([]byte, size-offset)
 	n, err := file.ReadAt(buf, offset)
 	if err != nil && err != io.EOF {
-		return nil, offset, err
+		return nil, offset, nil, err
 	}
 
-	return buf[:n], offset + int64(n), nil
+	return buf[:n], offset + int64(n), stat, nil
 }
@@ -17,6 +17,24 @@ func DecodeCommandOutput(data []byte) string {
 func SetCmdWindowHidden(cmd *exec.Cmd) {
 }
 
+func SetCmdProcessGroup(cmd *exec.Cmd) {
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
+}
+
+func KillProcessTree(p *os.Process) error {
+	if err := syscall.Kill(-p.Pid, syscall.SIGKILL); err == nil {
+		return nil
+	}
+	return p.Kill()
+}
+
+func ProcessStateSignal(state *os.ProcessState) string {
+	if status, ok := state.Sys().(syscall.WaitStatus); ok && status.Signaled() {
+		return status.Signal().String()
+	}
+	return ""
+}
+
 func SendExitSignal(p *os.Process) error {
 	return p.Signal(syscall.SIGINT)
 }
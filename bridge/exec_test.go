@@ -0,0 +1,106 @@
+package bridge
+
+import (
+	"runtime"
+	"strings"
+	"testing"
+)
+
+func TestExecFailureOutput(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("needs sh")
+	}
+
+	a := &App{}
+
+	tests := []struct {
+		name   string
+		script string
+		want   string
+	}{
+		{"output", "echo failed; exit 1", "exit status 1: failed"},
+		{"no output", "exit 2", "exit status 2"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := a.Exec("sh", []string{"-c", tt.script}, ExecOptions{})
+			if result.Flag {
+				t.Fatal("expected failure")
+			}
+			if result.Data != tt.want {
+				t.Errorf("data = %q, want %q", result.Data, tt.want)
+			}
+		})
+	}
+}
+
+func TestExecTimeout(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("needs sh")
+	}
+
+	a := &App{}
+
+	result := a.Exec("sh", []string{"-c", "echo started; sleep 10"}, ExecOptions{Timeout: 1})
+	if result.Flag {
+		t.Fatal("expected failure")
+	}
+	if !strings.HasPrefix(result.Data, "timed out after 1 seconds") || !strings.HasSuffix(result.Data, "started") {
+		t.Errorf("data = %q", result.Data)
+	}
+}
+
+func TestExecWithResult(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("needs sh")
+	}
+
+	a := &App{}
+
+	tests := []struct {
+		name    string
+		script  string
+		options ExecOptions
+		want    ExecResult
+	}{
+		{
+			name:   "success",
+			script: "echo out; echo warning >&2",
+			want:   ExecResult{Flag: true, Stdout: "out", Stderr: "warning", ExitCode: 0},
+		},
+		{
+			name:   "exit code",
+			script: "echo partial; echo invalid config >&2; exit 3",
+			want:   ExecResult{Stdout: "partial", Stderr: "invalid config", ExitCode: 3, Error: "exit status 3"},
+		},
+		{
+			name:   "signal",
+			script: "echo started; kill -TERM $$",
+			want:   ExecResult{Stdout: "started", ExitCode: -1, Signal: "terminated", Error: "signal: terminated"},
+		},
+		{
+			name:    "timeout",
+			script:  "echo started; exec sleep 10",
+			options: ExecOptions{Timeout: 1},
+			want:    ExecResult{Stdout: "started", ExitCode: -1, Signal: "killed", Error: "timed out after 1 seconds"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := a.ExecWithResult("sh", []string{"-c", tt.script}, tt.options)
+			if result.Duration < 0 || result.Duration > 5000 {
+				t.Errorf("duration = %d", result.Duration)
+			}
+			result.Duration = 0
+			if result != tt.want {
+				t.Errorf("result = %+v, want %+v", result, tt.want)
+			}
+		})
+	}
+
+	if result := a.ExecWithResult("does-not-exist-here", nil, ExecOptions{}); result.Flag || result.ExitCode != -1 || result.Error == "" {
+		t.Errorf("missing command = %+v", result)
+	}
+}
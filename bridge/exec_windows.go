@@ -7,6 +7,7 @@ import (
 	"math"
 	"os"
 	"os/exec"
+	"strconv"
 	"syscall"
 	"unicode/utf16"
 	"unicode/utf8"
@@ -66,6 +67,23 @@ func SetCmdWindowHidden(cmd *exec.Cmd) {
 	}
 }
 
+func SetCmdProcessGroup(cmd *exec.Cmd) {
+	// CREATE_NEW_PROCESS_GROUP is already set by SetCmdWindowHidden
+}
+
+func KillProcessTree(p *os.Process) error {
+	cmd := exec.Command("taskkill", "/T", "/F", "/PID", strconv.Itoa(p.Pid))
+	SetCmdWindowHidden(cmd)
+	if err := cmd.Run(); err != nil {
+		return p.Kill()
+	}
+	return nil
+}
+
+func ProcessStateSignal(state *os.ProcessState) string {
+	return ""
+}
+
 func SendExitSignal(p *os.Process) error {
 	if ret, _, err := procFreeConsole.Call(); ret == 0 && err != windows.ERROR_INVALID_HANDLE {
 		return err
@@ -0,0 +1,238 @@
+package bridge
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"unicode/utf8"
+)
+
+const (
+	defaultChunkSize = 1 << 20
+	maxChunkSize     = 4 << 20
+)
+
+type fileHandle struct {
+	mu     sync.Mutex
+	file   *os.File
+	offset int64
+	mode   string
+}
+
+var (
+	fileHandleCounter atomic.Uint64
+	fileHandleMu      sync.Mutex
+	fileHandleMap     = make(map[string]*fileHandle)
+)
+
+func (a *App) OpenFileHandle(path string, options FileHandleOptions) FlagResult {
+	log.Printf("OpenFileHandle [%s %s]: %s", options.Flag, options.Mode, path)
+
+	fullPath, err := sandboxPath(path, options.Token)
+	if err != nil {
+		return FlagResult{false, err.Error()}
+	}
+
+	mode := options.Mode
+	if mode == "" {
+		mode = Binary
+	}
+	if mode != Binary && mode != Text {
+		return FlagResult{false, "Unsupported IO mode: " + mode}
+	}
+
+	var flag int
+	switch options.Flag {
+	case "", "r":
+		flag = os.O_RDONLY
+	case "w":
+		flag = os.O_WRONLY | os.O_CREATE | os.O_TRUNC
+	case "a":
+		flag = os.O_WRONLY | os.O_CREATE
+	case "rw":
+		flag = os.O_RDWR | os.O_CREATE
+	default:
+		return FlagResult{false, "Unsupported flag: " + options.Flag}
+	}
+
+	if flag&os.O_CREATE != 0 {
+		if err := os.MkdirAll(filepath.Dir(fullPath), os.ModePerm); err != nil {
+			return FlagResult{false, err.Error()}
+		}
+	}
+
+	file, err := os.OpenFile(fullPath, flag, 0644)
+	if err != nil {
+		return FlagResult{false, err.Error()}
+	}
+
+	handle := &fileHandle{file: file, mode: mode}
+
+	// appends go through WriteAt like every other write, so start at the end instead of using O_APPEND
+	if options.Flag == "a" {
+		stat, err := file.Stat()
+		if err != nil {
+			file.Close()
+			return FlagResult{false, err.Error()}
+		}
+		handle.offset = stat.Size()
+	}
+
+	id := strconv.FormatUint(fileHandleCounter.Add(1), 10)
+
+	fileHandleMu.Lock()
+	fileHandleMap[id] = handle
+	fileHandleMu.Unlock()
+
+	return FlagResult{true, id}
+}
+
+// ReadChunk reads up to size bytes (default 1 MiB, at most 4 MiB) from the handle's
+// current offset. In Text mode a multi-byte character is never split across chunks.
+func (a *App) ReadChunk(id string, size int) FlagResult {
+	log.Printf("ReadChunk: %s %d", id, size)
+
+	handle, err := getFileHandle(id)
+	if err != nil {
+		return FlagResult{false, err.Error()}
+	}
+
+	if size <= 0 {
+		size = defaultChunkSize
+	}
+	size = min(size, maxChunkSize)
+
+	handle.mu.Lock()
+	defer handle.mu.Unlock()
+
+	buf := make([]byte, size)
+	n, err := handle.file.ReadAt(buf, handle.offset)
+	eof := errors.Is(err, io.EOF)
+	if err != nil && !eof {
+		return FlagResult{false, err.Error()}
+	}
+	buf = buf[:n]
+
+	if handle.mode == Text && !eof {
+		if length := completeRunesLength(buf); length > 0 {
+			buf = buf[:length]
+		}
+	}
+	handle.offset += int64(len(buf))
+
+	chunk := FileChunk{Offset: handle.offset, EOF: eof}
+	if handle.mode == Text {
+		chunk.Data = string(buf)
+	} else {
+		chunk.Data = base64.StdEncoding.EncodeToString(buf)
+	}
+
+	b, err := json.Marshal(chunk)
+	if err != nil {
+		return FlagResult{false, err.Error()}
+	}
+
+	return FlagResult{true, string(b)}
+}
+
+func (a *App) WriteChunk(id string, content string) FlagResult {
+	log.Printf("WriteChunk: %s", id)
+
+	handle, err := getFileHandle(id)
+	if err != nil {
+		return FlagResult{false, err.Error()}
+	}
+
+	handle.mu.Lock()
+	defer handle.mu.Unlock()
+
+	var data []byte
+	if handle.mode == Text {
+		data = []byte(content)
+	} else {
+		data, err = base64.StdEncoding.DecodeString(content)
+		if err != nil {
+			return FlagResult{false, err.Error()}
+		}
+	}
+
+	if len(data) > maxChunkSize {
+		return FlagResult{false, "chunk exceeds " + strconv.Itoa(maxChunkSize) + " bytes"}
+	}
+
+	n, err := handle.file.WriteAt(data, handle.offset)
+	handle.offset += int64(n)
+	if err != nil {
+		return FlagResult{false, err.Error()}
+	}
+
+	return FlagResult{true, strconv.FormatInt(handle.offset, 10)}
+}
+
+func (a *App) CloseFileHandle(id string) FlagResult {
+	log.Printf("CloseFileHandle: %s", id)
+
+	fileHandleMu.Lock()
+	handle, ok := fileHandleMap[id]
+	delete(fileHandleMap, id)
+	fileHandleMu.Unlock()
+
+	if !ok {
+		return FlagResult{false, "file handle not found"}
+	}
+
+	handle.mu.Lock()
+	defer handle.mu.Unlock()
+
+	if err := handle.file.Close(); err != nil {
+		return FlagResult{false, err.Error()}
+	}
+
+	return FlagResult{true, "Success"}
+}
+
+// CloseFileHandles closes every open handle. It is called when the frontend is (re)loaded,
+// which loses every id it was given.
+func CloseFileHandles() {
+	fileHandleMu.Lock()
+	handles := fileHandleMap
+	fileHandleMap = make(map[string]*fileHandle)
+	fileHandleMu.Unlock()
+
+	for _, handle := range handles {
+		handle.mu.Lock()
+		handle.file.Close()
+		handle.mu.Unlock()
+	}
+}
+
+func getFileHandle(id string) (*fileHandle, error) {
+	fileHandleMu.Lock()
+	defer fileHandleMu.Unlock()
+
+	handle, ok := fileHandleMap[id]
+	if !ok {
+		return nil, errors.New("file handle not found")
+	}
+	return handle, nil
+}
+
+// completeRunesLength returns the length of buf without a trailing, partially read UTF-8 sequence
+func completeRunesLength(buf []byte) int {
+	for i := len(buf) - 1; i >= 0 && i >= len(buf)-utf8.UTFMax; i-- {
+		if utf8.RuneStart(buf[i]) {
+			if !utf8.FullRune(buf[i:]) {
+				return i
+			}
+			break
+		}
+	}
+	return len(buf)
+}
@@ -0,0 +1,148 @@
+package bridge
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func openTestHandle(t *testing.T, a *App, path string, options FileHandleOptions) string {
+	t.Helper()
+
+	result := a.OpenFileHandle(path, options)
+	if !result.Flag {
+		t.Fatal(result.Data)
+	}
+	t.Cleanup(func() { a.CloseFileHandle(result.Data) })
+	return result.Data
+}
+
+func readTestChunk(t *testing.T, a *App, id string, size int) FileChunk {
+	t.Helper()
+
+	result := a.ReadChunk(id, size)
+	if !result.Flag {
+		t.Fatal(result.Data)
+	}
+	var chunk FileChunk
+	if err := json.Unmarshal([]byte(result.Data), &chunk); err != nil {
+		t.Fatal(err)
+	}
+	return chunk
+}
+
+func TestFileHandleChunks(t *testing.T) {
+	dir := useSandbox(t)
+	a := &App{}
+
+	id := openTestHandle(t, a, "data/chunks.bin", FileHandleOptions{Flag: "w"})
+	for i, content := range []string{"hello ", "world"} {
+		result := a.WriteChunk(id, base64.StdEncoding.EncodeToString([]byte(content)))
+		if want := []string{"6", "11"}[i]; !result.Flag || result.Data != want {
+			t.Fatalf("write %d = %+v, want offset %s", i, result, want)
+		}
+	}
+	a.CloseFileHandle(id)
+
+	id = openTestHandle(t, a, "data/chunks.bin", FileHandleOptions{Flag: "a"})
+	if result := a.WriteChunk(id, base64.StdEncoding.EncodeToString([]byte("!"))); result.Data != "12" {
+		t.Fatalf("append = %+v", result)
+	}
+	a.CloseFileHandle(id)
+
+	if b, _ := os.ReadFile(filepath.Join(dir, "data", "chunks.bin")); string(b) != "hello world!" {
+		t.Fatalf("file = %q", b)
+	}
+
+	id = openTestHandle(t, a, "data/chunks.bin", FileHandleOptions{})
+	var got []string
+	for _, want := range []FileChunk{
+		{Data: "hello", Offset: 5},
+		{Data: " worl", Offset: 10},
+		{Data: "d!", Offset: 12, EOF: true},
+		{Data: "", Offset: 12, EOF: true},
+	} {
+		chunk := readTestChunk(t, a, id, 5)
+		data, _ := base64.StdEncoding.DecodeString(chunk.Data)
+		if string(data) != want.Data || chunk.Offset != want.Offset || chunk.EOF != want.EOF {
+			t.Errorf("chunk = %q %+v, want %+v", data, chunk, want)
+		}
+		got = append(got, string(data))
+	}
+	if strings.Join(got, "") != "hello world!" {
+		t.Errorf("read %q", got)
+	}
+
+	if result := a.WriteChunk(id, "aGk="); result.Flag {
+		t.Error("wrote through a read only handle")
+	}
+}
+
+func TestFileHandleMaxChunk(t *testing.T) {
+	dir := useSandbox(t)
+	a := &App{}
+
+	content := bytes.Repeat([]byte("x"), maxChunkSize+10)
+	os.MkdirAll(filepath.Join(dir, "data"), os.ModePerm)
+	os.WriteFile(filepath.Join(dir, "data", "big.txt"), content, 0644)
+
+	id := openTestHandle(t, a, "data/big.txt", FileHandleOptions{Mode: Text})
+	if chunk := readTestChunk(t, a, id, maxChunkSize*2); len(chunk.Data) != maxChunkSize || chunk.EOF {
+		t.Errorf("read %d bytes, eof %v, want at most %d", len(chunk.Data), chunk.EOF, maxChunkSize)
+	}
+	if chunk := readTestChunk(t, a, id, 0); len(chunk.Data) != 10 || !chunk.EOF {
+		t.Errorf("rest = %d bytes, eof %v", len(chunk.Data), chunk.EOF)
+	}
+
+	id = openTestHandle(t, a, "data/out.txt", FileHandleOptions{Flag: "w", Mode: Text})
+	if result := a.WriteChunk(id, string(content)); result.Flag {
+		t.Error("accepted a chunk over the limit")
+	}
+}
+
+func TestFileHandleTextKeepsRunesWhole(t *testing.T) {
+	dir := useSandbox(t)
+	a := &App{}
+
+	// 1, 2, 3 and 4 byte characters
+	content := strings.Repeat("a¢€😀", 4)
+	os.MkdirAll(filepath.Join(dir, "data"), os.ModePerm)
+	os.WriteFile(filepath.Join(dir, "data", "runes.txt"), []byte(content), 0644)
+
+	for size := 4; size <= 7; size++ {
+		id := openTestHandle(t, a, "data/runes.txt", FileHandleOptions{Mode: Text})
+
+		var text strings.Builder
+		for i := 0; ; i++ {
+			chunk := readTestChunk(t, a, id, size)
+			if strings.ContainsRune(chunk.Data, '�') || chunk.Offset != int64(text.Len()+len(chunk.Data)) {
+				t.Fatalf("size %d: chunk %d = %+v", size, i, chunk)
+			}
+			text.WriteString(chunk.Data)
+			if chunk.EOF {
+				break
+			}
+			if chunk.Data == "" || i > len(content) {
+				t.Fatalf("size %d: no progress", size)
+			}
+		}
+		if text.String() != content {
+			t.Errorf("size %d: read %q", size, text.String())
+		}
+	}
+}
+
+func TestCloseFileHandles(t *testing.T) {
+	useSandbox(t)
+	a := &App{}
+
+	id := openTestHandle(t, a, "data/closed.txt", FileHandleOptions{Flag: "w"})
+	CloseFileHandles()
+	if result := a.WriteChunk(id, "aGk="); result.Flag || result.Data != "file handle not found" {
+		t.Errorf("handle outlived CloseFileHandles: %+v", result)
+	}
+}
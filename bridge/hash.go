@@ -0,0 +1,220 @@
+package bridge
+
+import (
+	"bufio"
+	"crypto/md5"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/sha512"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+	"log"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"lukechampine.com/blake3"
+)
+
+// bsdChecksumLine matches the tagged format written by `shasum --tag` and BSD tools: SHA256 (file) = hash
+var bsdChecksumLine = regexp.MustCompile(`^([A-Za-z0-9-]+) \((.+)\) = ([0-9A-Fa-f]+)$`)
+
+func (a *App) FileHash(path string, algo string, options FileOptions) FlagResult {
+	log.Printf("FileHash [%s]: %s", algo, path)
+
+	fullPath, err := sandboxPath(path, options.Token)
+	if err != nil {
+		return FlagResult{false, err.Error()}
+	}
+
+	sum, err := hashFile(fullPath, algo)
+	if err != nil {
+		return FlagResult{false, err.Error()}
+	}
+
+	return FlagResult{true, sum}
+}
+
+// VerifyChecksumFile checks targetPath against its entry in a checksum file such as
+// checksums.txt or a .sha256sum file. Both the `<hash>  <filename>` format of sha256sum
+// and friends and the tagged BSD format are understood; a file holding nothing but a
+// hash applies to the target whatever its name.
+func (a *App) VerifyChecksumFile(checksumsPath string, targetPath string, options FileOptions) FlagResult {
+	log.Printf("VerifyChecksumFile: %s -> %s", checksumsPath, targetPath)
+
+	fullPath, err := sandboxPath(targetPath, options.Token)
+	if err != nil {
+		return FlagResult{false, err.Error()}
+	}
+	checksumsFullPath, err := sandboxPath(checksumsPath, options.Token)
+	if err != nil {
+		return FlagResult{false, err.Error()}
+	}
+
+	algo, expected, err := findChecksum(checksumsFullPath, filepath.Base(fullPath))
+	if err != nil {
+		return FlagResult{false, err.Error()}
+	}
+
+	actual, err := hashFile(fullPath, algo)
+	if err != nil {
+		return FlagResult{false, err.Error()}
+	}
+
+	if actual != expected {
+		return FlagResult{false, fmt.Sprintf("%s mismatch: %s, expected %s, got %s", strings.ToUpper(algo), filepath.Base(fullPath), expected, actual)}
+	}
+
+	return FlagResult{true, "Success"}
+}
+
+func newHash(algo string) (hash.Hash, error) {
+	switch normalizeHashAlgo(algo) {
+	case "md5":
+		return md5.New(), nil
+	case "sha1":
+		return sha1.New(), nil
+	case "sha256":
+		return sha256.New(), nil
+	case "sha512":
+		return sha512.New(), nil
+	case "blake3":
+		return blake3.New(32, nil), nil
+	}
+	return nil, errors.New("Unsupported hash algorithm: " + algo)
+}
+
+func normalizeHashAlgo(algo string) string {
+	return strings.ReplaceAll(strings.ToLower(algo), "-", "")
+}
+
+func hashFile(fullPath string, algo string) (string, error) {
+	h, err := newHash(algo)
+	if err != nil {
+		return "", err
+	}
+
+	file, err := os.Open(fullPath)
+	if err != nil {
+		return "", err
+	}
+	defer file.Close()
+
+	stat, err := file.Stat()
+	if err != nil {
+		return "", err
+	}
+	if stat.IsDir() {
+		return "", errors.New("path is a directory")
+	}
+
+	if _, err := io.Copy(h, file); err != nil {
+		return "", err
+	}
+
+	return fmt.Sprintf("%x", h.Sum(nil)), nil
+}
+
+// parseChecksum splits an "<algorithm>:<hex>" checksum as used in RequestOptions.Checksum
+// and GitHub release asset digests
+func parseChecksum(checksum string) (string, string, error) {
+	algo, sum, ok := strings.Cut(checksum, ":")
+	if !ok || sum == "" {
+		return "", "", errors.New("invalid checksum, expected <algorithm>:<hex>: " + checksum)
+	}
+	if _, err := newHash(algo); err != nil {
+		return "", "", err
+	}
+	return normalizeHashAlgo(algo), strings.ToLower(sum), nil
+}
+
+// requestChecksum returns the algorithm and expected hash a download is verified against, if any
+func requestChecksum(options RequestOptions) (string, string, error) {
+	if options.Checksum != "" {
+		return parseChecksum(options.Checksum)
+	}
+	if options.Sha256 != "" {
+		return "sha256", strings.ToLower(options.Sha256), nil
+	}
+	return "", "", nil
+}
+
+// findChecksum returns the algorithm and hash listed for name in a checksum file
+func findChecksum(checksumsPath string, name string) (string, string, error) {
+	file, err := os.Open(checksumsPath)
+	if err != nil {
+		return "", "", err
+	}
+	defer file.Close()
+
+	var lines []string
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		if line := strings.TrimSpace(scanner.Text()); line != "" && !strings.HasPrefix(line, "#") {
+			lines = append(lines, line)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return "", "", err
+	}
+
+	if len(lines) == 1 && !strings.ContainsAny(lines[0], " \t") {
+		return checksumAlgo(checksumsPath, lines[0]), strings.ToLower(lines[0]), nil
+	}
+
+	for _, line := range lines {
+		if m := bsdChecksumLine.FindStringSubmatch(line); m != nil {
+			if checksumFileName(m[2]) == name {
+				if _, err := newHash(m[1]); err != nil {
+					return "", "", err
+				}
+				return normalizeHashAlgo(m[1]), strings.ToLower(m[3]), nil
+			}
+			continue
+		}
+
+		sum, file, ok := strings.Cut(line, " ")
+		if !ok {
+			continue
+		}
+		// the second separator character is a space in text mode and an asterisk in binary mode
+		file = strings.TrimPrefix(strings.TrimLeft(file, " \t"), "*")
+		if checksumFileName(file) == name {
+			return checksumAlgo(checksumsPath, sum), strings.ToLower(sum), nil
+		}
+	}
+
+	return "", "", errors.New("no checksum for " + name + " in " + filepath.Base(checksumsPath))
+}
+
+func checksumFileName(file string) string {
+	return path.Base(strings.ReplaceAll(file, "\\", "/"))
+}
+
+// checksumAlgo infers the algorithm from the checksum file name, falling back to the hash
+// length. BLAKE3 and SHA-256 share a length, so BLAKE3 needs a hint in the file name.
+func checksumAlgo(checksumsPath string, sum string) string {
+	name := strings.ToLower(filepath.Base(checksumsPath))
+	if strings.Contains(name, "b3sum") {
+		return "blake3"
+	}
+	for _, algo := range []string{"blake3", "sha512", "sha256", "sha1", "md5"} {
+		if strings.Contains(name, algo) {
+			return algo
+		}
+	}
+
+	switch len(sum) {
+	case 32:
+		return "md5"
+	case 40:
+		return "sha1"
+	case 128:
+		return "sha512"
+	}
+	return "sha256"
+}
@@ -0,0 +1,161 @@
+package bridge
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const (
+	testSha256 = "2cf24dba5fb0a30e26e83b2ac5b9e29e1b161e5c1fa7425e73043362938b9824" // sha256 of "hello"
+	testBlake3 = "ea8f163db38682925e4491c5e58d4bb3506ef8c14eb78a86e908c5624a67200f" // blake3 of "hello"
+	testMd5    = "5d41402abc4b2a76b9719d911017c592"                                 // md5 of "hello"
+)
+
+func TestChecksumAlgo(t *testing.T) {
+	tests := []struct {
+		file string
+		sum  string
+		want string
+	}{
+		{"app.b3sum", testBlake3, "blake3"},
+		{"SHA256SUMS", testSha256, "sha256"},
+		{"app.tar.gz.sha512", testSha256, "sha512"},
+		{"checksums.txt", testSha256, "sha256"},
+		{"checksums.txt", testMd5, "md5"},
+		{"checksums.txt", strings.Repeat("a", 40), "sha1"},
+		{"checksums.txt", strings.Repeat("a", 128), "sha512"},
+	}
+
+	for _, tt := range tests {
+		if got := checksumAlgo(filepath.Join("dir", tt.file), tt.sum); got != tt.want {
+			t.Errorf("%s with %d hex digits = %s, want %s", tt.file, len(tt.sum), got, tt.want)
+		}
+	}
+}
+
+func TestFindChecksum(t *testing.T) {
+	tests := []struct {
+		name     string
+		file     string
+		content  string
+		wantAlgo string
+		wantSum  string
+		wantErr  string
+	}{
+		{
+			name:     "gnu text mode",
+			file:     "checksums.txt",
+			content:  "# release\n" + strings.Repeat("0", 64) + "  other.zip\n" + strings.ToUpper(testSha256) + "  app.zip\n",
+			wantAlgo: "sha256",
+			wantSum:  testSha256,
+		},
+		{
+			name:     "gnu binary mode",
+			file:     "checksums.txt",
+			content:  testMd5 + " *dist/app.zip\n",
+			wantAlgo: "md5",
+			wantSum:  testMd5,
+		},
+		{
+			name:     "bsd tagged",
+			file:     "checksums.txt",
+			content:  "SHA256 (other.zip) = " + strings.Repeat("0", 64) + "\nBLAKE3 (app.zip) = " + testBlake3 + "\n",
+			wantAlgo: "blake3",
+			wantSum:  testBlake3,
+		},
+		{
+			name:    "bsd tagged for another file",
+			file:    "checksums.txt",
+			content: "SHA256 (app.zip.sig) = " + testSha256 + "\n",
+			wantErr: "no checksum for app.zip in checksums.txt",
+		},
+		{
+			name:     "single hash",
+			file:     "app.zip.sha256",
+			content:  testSha256 + "\n",
+			wantAlgo: "sha256",
+			wantSum:  testSha256,
+		},
+		{
+			name:     "b3sum hint",
+			file:     "app.zip.b3sum",
+			content:  testBlake3 + "  app.zip\n",
+			wantAlgo: "blake3",
+			wantSum:  testBlake3,
+		},
+		{
+			name:     "bare 64 hex digits",
+			file:     "app.zip.digest",
+			content:  testBlake3,
+			wantAlgo: "sha256",
+			wantSum:  testBlake3,
+		},
+		{
+			name:    "missing entry",
+			file:    "checksums.txt",
+			content: testSha256 + "  other.zip\n",
+			wantErr: "no checksum for app.zip in checksums.txt",
+		},
+	}
+
+	dir := t.TempDir()
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(dir, tt.file)
+			if err := os.WriteFile(path, []byte(tt.content), 0644); err != nil {
+				t.Fatal(err)
+			}
+
+			algo, sum, err := findChecksum(path, "app.zip")
+			if tt.wantErr != "" {
+				if err == nil || err.Error() != tt.wantErr {
+					t.Fatalf("err = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if algo != tt.wantAlgo || sum != tt.wantSum {
+				t.Errorf("got %s %s, want %s %s", algo, sum, tt.wantAlgo, tt.wantSum)
+			}
+		})
+	}
+}
+
+func TestVerifyChecksumFile(t *testing.T) {
+	dir := useSandbox(t)
+	a := &App{}
+
+	os.MkdirAll(filepath.Join(dir, "data"), os.ModePerm)
+	os.WriteFile(filepath.Join(dir, "data", "app.zip"), []byte("hello"), 0644)
+
+	tests := []struct {
+		name    string
+		file    string
+		content string
+		want    FlagResult
+	}{
+		{"sha256", "checksums.txt", testSha256 + "  app.zip\n", FlagResult{true, "Success"}},
+		{"blake3", "app.zip.b3sum", testBlake3 + "  app.zip\n", FlagResult{true, "Success"}},
+		{
+			"mismatch",
+			"SHA256SUMS",
+			strings.Repeat("0", 64) + "  app.zip\n",
+			FlagResult{false, "SHA256 mismatch: app.zip, expected " + strings.Repeat("0", 64) + ", got " + testSha256},
+		},
+		{"missing", "SHA256SUMS.txt", testSha256 + "  other.zip\n", FlagResult{false, "no checksum for app.zip in SHA256SUMS.txt"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			os.WriteFile(filepath.Join(dir, "data", tt.file), []byte(tt.content), 0644)
+
+			if got := a.VerifyChecksumFile("data/"+tt.file, "data/app.zip", FileOptions{}); got != tt.want {
+				t.Errorf("got %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,115 @@
+package bridge
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"maps"
+	"net/http"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+// httpCacheEntry is what RequestOptions.Cache keeps for a URL in data/.cache/http. Requests
+// stores the response body with it, Download only the path the body was saved to.
+type httpCacheEntry struct {
+	URL          string      `json:"url"`
+	Path         string      `json:"path,omitempty"`
+	ETag         string      `json:"etag,omitempty"`
+	LastModified string      `json:"lastModified,omitempty"`
+	Header       http.Header `json:"header,omitempty"`
+	Body         string      `json:"body,omitempty"`
+}
+
+// httpCacheKey identifies a GET response by its URL and the request headers, which may
+// select a different representation (User-Agent, Accept, Authorization and the like).
+// The validators setConditional adds are left out.
+func httpCacheKey(url string, header http.Header) string {
+	var b strings.Builder
+	b.WriteString("GET " + url)
+
+	for _, key := range slices.Sorted(maps.Keys(header)) {
+		if key == "If-None-Match" || key == "If-Modified-Since" {
+			continue
+		}
+		b.WriteString("\n" + key + ": " + strings.Join(header[key], ", "))
+	}
+
+	return b.String()
+}
+
+func httpCacheFile(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return filepath.Join(resolvePath("data/.cache/http"), hex.EncodeToString(sum[:])+".json")
+}
+
+// loadHTTPCache returns the entry stored under key, or nil when there is none
+func loadHTTPCache(key string) *httpCacheEntry {
+	b, err := os.ReadFile(httpCacheFile(key))
+	if err != nil {
+		return nil
+	}
+
+	var entry httpCacheEntry
+	if err := json.Unmarshal(b, &entry); err != nil {
+		return nil
+	}
+
+	return &entry
+}
+
+// storeHTTPCache saves entry under key if the response carries validators to revalidate
+// it with later, and drops any previous entry otherwise
+func storeHTTPCache(key string, entry httpCacheEntry, resp *http.Response) {
+	entry.ETag = resp.Header.Get("ETag")
+	entry.LastModified = resp.Header.Get("Last-Modified")
+
+	if entry.ETag == "" && entry.LastModified == "" {
+		removeHTTPCache(key)
+		return
+	}
+
+	b, err := json.Marshal(entry)
+	if err != nil {
+		return
+	}
+
+	path := httpCacheFile(key)
+	if err := os.MkdirAll(filepath.Dir(path), os.ModePerm); err != nil {
+		return
+	}
+	_ = writeFileAtomic(path, b)
+}
+
+func removeHTTPCache(key string) {
+	_ = os.Remove(httpCacheFile(key))
+}
+
+// setConditional asks the server to answer 304 Not Modified if the cached response is
+// still current, unless the caller already sent its own conditions
+func (e *httpCacheEntry) setConditional(req *http.Request) {
+	if req.Header.Get("If-None-Match") != "" || req.Header.Get("If-Modified-Since") != "" {
+		return
+	}
+	if e.ETag != "" {
+		req.Header.Set("If-None-Match", e.ETag)
+	}
+	if e.LastModified != "" {
+		req.Header.Set("If-Modified-Since", e.LastModified)
+	}
+}
+
+// notModifiedHeader merges the headers of a 304 response, which may carry updated
+// values, over the cached ones
+func (e *httpCacheEntry) notModifiedHeader(resp *http.Response) http.Header {
+	header := e.Header.Clone()
+	if header == nil {
+		header = make(http.Header)
+	}
+	for key, values := range resp.Header {
+		header[key] = values
+	}
+	return header
+}
@@ -0,0 +1,82 @@
+package bridge
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+)
+
+func TestHTTPCacheKey(t *testing.T) {
+	key := httpCacheKey("https://example.com/sub", requestHeaders(map[string]string{"User-Agent": "a", "Accept": "*/*"}))
+
+	same := http.Header{}
+	same.Set("Accept", "*/*")
+	same.Set("User-Agent", "a")
+	same.Set("If-None-Match", `"v1"`)
+	if got := httpCacheKey("https://example.com/sub", same); got != key {
+		t.Errorf("key depends on header order or validators:\n%s\n%s", got, key)
+	}
+
+	for name, header := range map[string]map[string]string{
+		"user agent":    {"User-Agent": "b", "Accept": "*/*"},
+		"missing":       {"User-Agent": "a"},
+		"authorization": {"User-Agent": "a", "Accept": "*/*", "Authorization": "Bearer x"},
+	} {
+		if httpCacheKey("https://example.com/sub", requestHeaders(header)) == key {
+			t.Errorf("%s: same key for different request headers", name)
+		}
+	}
+}
+
+func TestRequestsCache(t *testing.T) {
+	useSandbox(t)
+
+	var (
+		mu          sync.Mutex
+		conditional []bool
+	)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		conditional = append(conditional, r.Header.Get("If-None-Match") != "")
+		mu.Unlock()
+
+		w.Header().Set("ETag", `"`+r.UserAgent()+`"`)
+		if r.Header.Get("If-None-Match") == `"`+r.UserAgent()+`"` {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		w.Write([]byte("body for " + r.UserAgent()))
+	}))
+	defer server.Close()
+
+	a := &App{}
+	request := func(userAgent string) HTTPResult {
+		t.Helper()
+		result := a.Requests(http.MethodGet, server.URL, map[string]string{"User-Agent": userAgent}, "", RequestOptions{Cache: true, Redirect: true})
+		if !result.Flag {
+			t.Fatal(result.Body)
+		}
+		return result
+	}
+
+	if result := request("a"); result.Status != http.StatusOK || result.Body != "body for a" {
+		t.Fatalf("first request: %+v", result)
+	}
+	if result := request("a"); result.Status != http.StatusNotModified || result.Body != "body for a" {
+		t.Fatalf("revalidated request: %+v", result)
+	}
+	// another user agent may get another body, it must not be answered from a's entry
+	if result := request("b"); result.Status != http.StatusOK || result.Body != "body for b" {
+		t.Fatalf("other user agent: %+v", result)
+	}
+
+	want := []bool{false, true, false}
+	mu.Lock()
+	defer mu.Unlock()
+	for i := range want {
+		if i >= len(conditional) || conditional[i] != want[i] {
+			t.Fatalf("conditional requests = %v, want %v", conditional, want)
+		}
+	}
+}
@@ -2,14 +2,14 @@ package bridge
 
 import (
 	"archive/tar"
-	"archive/zip"
 	"compress/gzip"
-	"crypto/sha256"
 	"encoding/base64"
+	"encoding/json"
 	"fmt"
 	"io"
 	"log"
 	"os"
+	"path"
 	"path/filepath"
 	"strings"
 
@@ -24,14 +24,16 @@ const (
 func (a *App) WriteFile(path string, content string, options IOOptions) FlagResult {
 	log.Printf("WriteFile [%s %s]: %s", options.Mode, options.Range, path)
 
-	fullPath := resolvePath(path)
+	fullPath, err := sandboxPath(path, options.Token)
+	if err != nil {
+		return FlagResult{false, err.Error()}
+	}
 
 	if err := os.MkdirAll(filepath.Dir(fullPath), os.ModePerm); err != nil {
 		return FlagResult{false, err.Error()}
 	}
 
 	var data []byte
-	var err error
 
 	switch options.Mode {
 	case Text:
@@ -45,6 +47,20 @@ func (a *App) WriteFile(path string, content string, options IOOptions) FlagResu
 		return FlagResult{false, "Unsupported IO mode: " + options.Mode}
 	}
 
+	if options.Range == "" {
+		if options.Backups > 0 {
+			if err := backupFile(fullPath, options.Backups); err != nil {
+				return FlagResult{false, err.Error()}
+			}
+		}
+		if options.Atomic {
+			if err := writeFileAtomic(fullPath, data); err != nil {
+				return FlagResult{false, err.Error()}
+			}
+			return FlagResult{true, "Success"}
+		}
+	}
+
 	file, err := os.OpenFile(fullPath, os.O_RDWR|os.O_CREATE, 0644)
 	if err != nil {
 		return FlagResult{false, err.Error()}
@@ -86,10 +102,120 @@ func (a *App) WriteFile(path string, content string, options IOOptions) FlagResu
 	return FlagResult{true, "Success"}
 }
 
+// writeFileAtomic writes data to a temporary file next to path, syncs it and renames it
+// over path, so readers never observe a partially written file.
+func writeFileAtomic(path string, data []byte) error {
+	// replace the file a symlink points to, not the link itself
+	if stat, err := os.Lstat(path); err == nil && stat.Mode()&os.ModeSymlink != 0 {
+		target, err := filepath.EvalSymlinks(path)
+		if err != nil {
+			// a dangling link is written through like os.WriteFile does
+			return os.WriteFile(path, data, 0644)
+		}
+		path = target
+	}
+
+	perm := os.FileMode(0644)
+	if stat, err := os.Stat(path); err == nil {
+		perm = stat.Mode().Perm()
+
+		// renaming over the file would detach it from its other hard links
+		if fileLinkCount(path) > 1 {
+			return os.WriteFile(path, data, perm)
+		}
+	}
+
+	dir := filepath.Dir(path)
+
+	tmp, err := os.CreateTemp(dir, "."+filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	tmpPath := tmp.Name()
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		os.Remove(tmpPath)
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		os.Remove(tmpPath)
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmpPath)
+		return err
+	}
+	if err := os.Chmod(tmpPath, perm); err != nil {
+		os.Remove(tmpPath)
+		return err
+	}
+	if err := os.Rename(tmpPath, path); err != nil {
+		os.Remove(tmpPath)
+		return err
+	}
+
+	syncDir(dir)
+
+	return nil
+}
+
+// syncDir flushes directory metadata so a completed rename survives power loss.
+// Not every platform supports syncing a directory, so errors are ignored.
+func syncDir(dir string) {
+	d, err := os.Open(dir)
+	if err != nil {
+		return
+	}
+	_ = d.Sync()
+	d.Close()
+}
+
+// backupFile copies path to path.bak.1, shifting older backups up and keeping at most keep of them.
+func backupFile(path string, keep int) error {
+	src, err := os.Open(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return err
+	}
+	defer src.Close()
+
+	_ = os.Remove(backupFilePath(path, keep))
+	for i := keep - 1; i >= 1; i-- {
+		if err := os.Rename(backupFilePath(path, i), backupFilePath(path, i+1)); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+	}
+
+	dst, err := os.Create(backupFilePath(path, 1))
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(dst, src); err != nil {
+		dst.Close()
+		return err
+	}
+	if err := dst.Sync(); err != nil {
+		dst.Close()
+		return err
+	}
+	return dst.Close()
+}
+
+func backupFilePath(path string, index int) string {
+	return fmt.Sprintf("%s.bak.%d", path, index)
+}
+
 func (a *App) ReadFile(path string, options IOOptions) FlagResult {
 	log.Printf("ReadFile [%s %s]: %s", options.Mode, options.Range, path)
 
-	fullPath := resolvePath(path)
+	fullPath, err := sandboxPath(path, options.Token)
+	if err != nil {
+		return FlagResult{false, err.Error()}
+	}
 
 	file, err := os.Open(fullPath)
 	if err != nil {
@@ -130,11 +256,17 @@ func (a *App) ReadFile(path string, options IOOptions) FlagResult {
 	}
 }
 
-func (a *App) MoveFile(source string, target string) FlagResult {
+func (a *App) MoveFile(source string, target string, options FileOptions) FlagResult {
 	log.Printf("MoveFile: %s -> %s", source, target)
 
-	fullSource := resolvePath(source)
-	fullTarget := resolvePath(target)
+	fullSource, err := sandboxPath(source, options.Token)
+	if err != nil {
+		return FlagResult{false, err.Error()}
+	}
+	fullTarget, err := sandboxPath(target, options.Token)
+	if err != nil {
+		return FlagResult{false, err.Error()}
+	}
 
 	if err := os.MkdirAll(filepath.Dir(fullTarget), os.ModePerm); err != nil {
 		return FlagResult{false, err.Error()}
@@ -147,10 +279,20 @@ func (a *App) MoveFile(source string, target string) FlagResult {
 	return FlagResult{true, "Success"}
 }
 
-func (a *App) RemoveFile(path string) FlagResult {
-	log.Printf("RemoveFile: %s", path)
+func (a *App) RemoveFile(path string, options RemoveOptions) FlagResult {
+	log.Printf("RemoveFile [trash=%v]: %s", options.Trash, path)
 
-	fullPath := resolvePath(path)
+	fullPath, err := sandboxPath(path, options.Token)
+	if err != nil {
+		return FlagResult{false, err.Error()}
+	}
+
+	if options.Trash {
+		if err := moveToTrash(fullPath); err != nil {
+			return FlagResult{false, err.Error()}
+		}
+		return FlagResult{true, "Success"}
+	}
 
 	if err := os.RemoveAll(fullPath); err != nil {
 		return FlagResult{false, err.Error()}
@@ -159,11 +301,17 @@ func (a *App) RemoveFile(path string) FlagResult {
 	return FlagResult{true, "Success"}
 }
 
-func (a *App) CopyFile(src string, dst string) FlagResult {
+func (a *App) CopyFile(src string, dst string, options FileOptions) FlagResult {
 	log.Printf("CopyFile: %s -> %s", src, dst)
 
-	srcPath := resolvePath(src)
-	dstPath := resolvePath(dst)
+	srcPath, err := sandboxPath(src, options.Token)
+	if err != nil {
+		return FlagResult{false, err.Error()}
+	}
+	dstPath, err := sandboxPath(dst, options.Token)
+	if err != nil {
+		return FlagResult{false, err.Error()}
+	}
 
 	srcFile, err := os.Open(srcPath)
 	if err != nil {
@@ -191,10 +339,13 @@ func (a *App) CopyFile(src string, dst string) FlagResult {
 	return FlagResult{true, "Success"}
 }
 
-func (a *App) MakeDir(path string) FlagResult {
+func (a *App) MakeDir(path string, options FileOptions) FlagResult {
 	log.Printf("MakeDir: %s", path)
 
-	fullPath := resolvePath(path)
+	fullPath, err := sandboxPath(path, options.Token)
+	if err != nil {
+		return FlagResult{false, err.Error()}
+	}
 
 	if err := os.MkdirAll(fullPath, os.ModePerm); err != nil {
 		return FlagResult{false, err.Error()}
@@ -203,10 +354,13 @@ func (a *App) MakeDir(path string) FlagResult {
 	return FlagResult{true, "Success"}
 }
 
-func (a *App) ReadDir(path string) FlagResult {
+func (a *App) ReadDir(path string, options FileOptions) FlagResult {
 	log.Printf("ReadDir: %s", path)
 
-	fullPath := resolvePath(path)
+	fullPath, err := sandboxPath(path, options.Token)
+	if err != nil {
+		return FlagResult{false, err.Error()}
+	}
 
 	files, err := os.ReadDir(fullPath)
 	if err != nil {
@@ -224,12 +378,103 @@ func (a *App) ReadDir(path string) FlagResult {
 	return FlagResult{true, strings.Join(result, "|")}
 }
 
-func (a *App) OpenDir(path string) FlagResult {
+func (a *App) ListDir(path string, options ListDirOptions) FlagResult {
+	log.Printf("ListDir [%d %s]: %s", options.Depth, options.Pattern, path)
+
+	fullPath, err := sandboxPath(path, options.Token)
+	if err != nil {
+		return FlagResult{false, err.Error()}
+	}
+
+	if _, err := matchListPattern(options.Pattern, DirEntry{}); err != nil {
+		return FlagResult{false, err.Error()}
+	}
+
+	depth := options.Depth
+	if depth == 0 {
+		depth = 1
+	}
+
+	result := []DirEntry{}
+	if err := listDir(fullPath, "", depth, options.Pattern, &result); err != nil {
+		return FlagResult{false, err.Error()}
+	}
+
+	b, err := json.Marshal(result)
+	if err != nil {
+		return FlagResult{false, err.Error()}
+	}
+
+	return FlagResult{true, string(b)}
+}
+
+// listDir appends the entries of dir to result, descending into subdirectories while
+// depth allows. Symlinked directories are reported but not followed to avoid cycles.
+func listDir(dir string, prefix string, depth int, pattern string, result *[]DirEntry) error {
+	files, err := os.ReadDir(dir)
+	if err != nil {
+		return err
+	}
+
+	for _, file := range files {
+		info, err := file.Info()
+		if err != nil {
+			continue
+		}
+
+		entry := DirEntry{
+			Name:    info.Name(),
+			Path:    path.Join(prefix, info.Name()),
+			Size:    info.Size(),
+			IsDir:   info.IsDir(),
+			Mode:    info.Mode().String(),
+			ModTime: info.ModTime().UnixMilli(),
+		}
+
+		if info.Mode()&os.ModeSymlink != 0 {
+			entry.IsSymlink = true
+			if target, err := os.Readlink(filepath.Join(dir, info.Name())); err == nil {
+				entry.Target = filepath.ToSlash(target)
+			}
+			if stat, err := os.Stat(filepath.Join(dir, info.Name())); err == nil {
+				entry.IsDir = stat.IsDir()
+			}
+		}
+
+		if matched, _ := matchListPattern(pattern, entry); matched {
+			*result = append(*result, entry)
+		}
+
+		if info.IsDir() && depth != 1 {
+			if err := listDir(filepath.Join(dir, info.Name()), entry.Path, depth-1, pattern, result); err != nil {
+				log.Printf("ListDir: %v", err)
+			}
+		}
+	}
+
+	return nil
+}
+
+func matchListPattern(pattern string, entry DirEntry) (bool, error) {
+	if pattern == "" {
+		return true, nil
+	}
+	name := entry.Name
+	if strings.Contains(pattern, "/") {
+		name = entry.Path
+	}
+	return path.Match(pattern, name)
+}
+
+func (a *App) OpenDir(path string, options FileOptions) FlagResult {
 	log.Printf("OpenDir: %s", path)
 
-	fullPath := resolvePath(path)
+	fullPath, err := sandboxPath(path, options.Token)
+	if err != nil {
+		return FlagResult{false, err.Error()}
+	}
 
-	err := browser.OpenURL(fullPath)
+	err = browser.OpenURL(fullPath)
 	if err != nil {
 		return FlagResult{false, err.Error()}
 	}
@@ -248,72 +493,52 @@ func (a *App) OpenURI(uri string) FlagResult {
 	return FlagResult{true, "Success"}
 }
 
-func (a *App) AbsolutePath(path string) FlagResult {
+func (a *App) AbsolutePath(path string, options FileOptions) FlagResult {
 	log.Printf("AbsolutePath: %s", path)
 
-	absPath := resolvePath(path)
+	absPath, err := sandboxPath(path, options.Token)
+	if err != nil {
+		return FlagResult{false, err.Error()}
+	}
 
 	return FlagResult{true, absPath}
 }
 
-func (a *App) UnzipZIPFile(path string, output string) FlagResult {
+func (a *App) UnzipZIPFile(path string, output string, options FileOptions) FlagResult {
 	log.Printf("UnzipZIPFile: %s -> %s", path, output)
 
-	fullPath := resolvePath(path)
-	outputPath := resolvePath(output)
-
-	archive, err := zip.OpenReader(fullPath)
+	fullPath, err := sandboxPath(path, options.Token)
+	if err != nil {
+		return FlagResult{false, err.Error()}
+	}
+	outputPath, err := sandboxPath(output, options.Token)
 	if err != nil {
 		return FlagResult{false, err.Error()}
 	}
-	defer archive.Close()
-
-	for _, f := range archive.File {
-		filePath, ok := archiveEntryPath(outputPath, f.Name)
-		if !ok {
-			continue
-		}
-
-		if f.FileInfo().IsDir() {
-			os.MkdirAll(filePath, os.ModePerm)
-			continue
-		}
-
-		if err := os.MkdirAll(filepath.Dir(filePath), os.ModePerm); err != nil {
-			continue
-		}
-
-		fileInArchive, err := f.Open()
-		if err != nil {
-			continue
-		}
-
-		dstFile, err := os.OpenFile(filePath, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, f.Mode())
-		if err != nil {
-			fileInArchive.Close()
-			continue
-		}
 
-		if _, err := io.Copy(dstFile, fileInArchive); err != nil {
-			fileInArchive.Close()
-			dstFile.Close()
-			continue
-		}
+	x, err := newArchiveExtractor(a, outputPath, ExtractOptions{})
+	if err != nil {
+		return FlagResult{false, err.Error()}
+	}
 
-		fileInArchive.Close()
-		if err := dstFile.Close(); err != nil {
-			continue
-		}
+	if err := x.extractZip(fullPath); err != nil {
+		return FlagResult{false, err.Error()}
 	}
 
 	return FlagResult{true, "Success"}
 }
 
-func (a *App) UnzipTarGZFile(path string, output string) FlagResult {
+func (a *App) UnzipTarGZFile(path string, output string, options FileOptions) FlagResult {
 	log.Printf("UnzipTarGZFile: %s -> %s", path, output)
 
-	fullPath := resolvePath(path)
-	outputPath := resolvePath(output)
+	fullPath, err := sandboxPath(path, options.Token)
+	if err != nil {
+		return FlagResult{false, err.Error()}
+	}
+	outputPath, err := sandboxPath(output, options.Token)
+	if err != nil {
+		return FlagResult{false, err.Error()}
+	}
 
 	gzipFile, err := os.Open(fullPath)
 	if err != nil {
@@ -327,54 +552,29 @@ func (a *App) UnzipTarGZFile(path string, output string) FlagResult {
 	}
 	defer gzipReader.Close()
 
-	tarReader := tar.NewReader(gzipReader)
-
-	for {
-		header, err := tarReader.Next()
-		if err == io.EOF {
-			break
-		}
-		if err != nil {
-			return FlagResult{false, err.Error()}
-		}
-
-		filePath, ok := archiveEntryPath(outputPath, header.Name)
-		if !ok {
-			continue
-		}
-
-		if header.Typeflag == tar.TypeDir {
-			os.MkdirAll(filePath, os.ModePerm)
-			continue
-		}
-
-		if err := os.MkdirAll(filepath.Dir(filePath), os.ModePerm); err != nil {
-			continue
-		}
-
-		dstFile, err := os.OpenFile(filePath, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, header.FileInfo().Mode())
-		if err != nil {
-			continue
-		}
-
-		if _, err := io.Copy(dstFile, tarReader); err != nil {
-			dstFile.Close()
-			continue
-		}
+	x, err := newArchiveExtractor(a, outputPath, ExtractOptions{})
+	if err != nil {
+		return FlagResult{false, err.Error()}
+	}
 
-		if err := dstFile.Close(); err != nil {
-			continue
-		}
+	if err := x.extractTar(tar.NewReader(gzipReader)); err != nil {
+		return FlagResult{false, err.Error()}
 	}
 
 	return FlagResult{true, "Success"}
 }
 
-func (a *App) UnzipGZFile(path string, output string) FlagResult {
+func (a *App) UnzipGZFile(path string, output string, options FileOptions) FlagResult {
 	log.Printf("UnzipGZFile: %s -> %s", path, output)
 
-	fullPath := resolvePath(path)
-	outputPath := resolvePath(output)
+	fullPath, err := sandboxPath(path, options.Token)
+	if err != nil {
+		return FlagResult{false, err.Error()}
+	}
+	outputPath, err := sandboxPath(output, options.Token)
+	if err != nil {
+		return FlagResult{false, err.Error()}
+	}
 
 	gzipFile, err := os.Open(fullPath)
 	if err != nil {
@@ -420,12 +620,15 @@ func archiveEntryPath(outputPath, entryName string) (string, bool) {
 	return filepath.ToSlash(targetPath), true
 }
 
-func (a *App) FileExists(path string) FlagResult {
+func (a *App) FileExists(path string, options FileOptions) FlagResult {
 	log.Printf("FileExists: %s", path)
 
-	path = resolvePath(path)
+	path, err := sandboxPath(path, options.Token)
+	if err != nil {
+		return FlagResult{false, err.Error()}
+	}
 
-	_, err := os.Stat(path)
+	_, err = os.Stat(path)
 	if err == nil {
 		return FlagResult{true, "true"}
 	}
@@ -437,29 +640,18 @@ func (a *App) FileExists(path string) FlagResult {
 	return FlagResult{false, err.Error()}
 }
 
-func (a *App) FileSHA256(path string) FlagResult {
+func (a *App) FileSHA256(path string, options FileOptions) FlagResult {
 	log.Printf("FileSHA256: %s", path)
 
-	fullPath := resolvePath(path)
-
-	file, err := os.Open(fullPath)
+	fullPath, err := sandboxPath(path, options.Token)
 	if err != nil {
 		return FlagResult{false, err.Error()}
 	}
-	defer file.Close()
 
-	stat, err := file.Stat()
+	sum, err := hashFile(fullPath, "sha256")
 	if err != nil {
 		return FlagResult{false, err.Error()}
 	}
-	if stat.IsDir() {
-		return FlagResult{false, "path is a directory"}
-	}
-
-	hash := sha256.New()
-	if _, err := io.Copy(hash, file); err != nil {
-		return FlagResult{false, err.Error()}
-	}
 
-	return FlagResult{true, fmt.Sprintf("%x", hash.Sum(nil))}
+	return FlagResult{true, sum}
 }
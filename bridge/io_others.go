@@ -0,0 +1,27 @@
+//go:build !windows
+
+package bridge
+
+import (
+	"errors"
+	"os"
+	"syscall"
+)
+
+// fileLinkCount returns the number of hard links to the file at path
+func fileLinkCount(path string) uint64 {
+	stat, err := os.Stat(path)
+	if err != nil {
+		return 1
+	}
+	if sys, ok := stat.Sys().(*syscall.Stat_t); ok {
+		return uint64(sys.Nlink)
+	}
+	return 1
+}
+
+// isCrossDeviceError reports whether a rename failed because source and target are on
+// different file systems
+func isCrossDeviceError(err error) bool {
+	return errors.Is(err, syscall.EXDEV)
+}
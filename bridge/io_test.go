@@ -0,0 +1,103 @@
+package bridge
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+)
+
+// useSandbox points the base path and the sandbox at a fresh temporary directory
+func useSandbox(t *testing.T) string {
+	t.Helper()
+
+	dir, err := filepath.EvalSymlinks(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	dir = filepath.ToSlash(dir)
+
+	Env.BasePath = dir
+	Config.SandboxGrants = nil
+	sandboxMu.Lock()
+	sandboxRoots = []string{dir}
+	resetSandboxGrants()
+	sandboxMu.Unlock()
+
+	return dir
+}
+
+func TestWriteFileAtomicKeepsSymlink(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("symlinks need extra privileges")
+	}
+
+	dir := useSandbox(t)
+	target := filepath.Join(dir, "real.yaml")
+	link := filepath.Join(dir, "user.yaml")
+
+	os.WriteFile(target, []byte("old"), 0600)
+	if err := os.Symlink(target, link); err != nil {
+		t.Fatal(err)
+	}
+
+	if result := (&App{}).WriteFile(link, "new", IOOptions{Mode: Text, Atomic: true}); !result.Flag {
+		t.Fatalf("WriteFile: %s", result.Data)
+	}
+
+	if stat, err := os.Lstat(link); err != nil || stat.Mode()&os.ModeSymlink == 0 {
+		t.Fatal("the symlink was replaced by a regular file")
+	}
+	if b, _ := os.ReadFile(target); string(b) != "new" {
+		t.Errorf("target = %q, want %q", b, "new")
+	}
+	if stat, _ := os.Stat(target); stat.Mode().Perm() != 0600 {
+		t.Errorf("permissions = %v, want 0600", stat.Mode().Perm())
+	}
+}
+
+func TestWriteFileAtomicKeepsHardlink(t *testing.T) {
+	dir := useSandbox(t)
+	path := filepath.Join(dir, "a.txt")
+	other := filepath.Join(dir, "b.txt")
+
+	os.WriteFile(path, []byte("old"), 0644)
+	if err := os.Link(path, other); err != nil {
+		t.Skip("hard links unsupported:", err)
+	}
+
+	if result := (&App{}).WriteFile(path, "new", IOOptions{Mode: Text, Atomic: true}); !result.Flag {
+		t.Fatalf("WriteFile: %s", result.Data)
+	}
+
+	if b, _ := os.ReadFile(other); string(b) != "new" {
+		t.Errorf("other link = %q, want %q", b, "new")
+	}
+}
+
+func TestWriteFileBackups(t *testing.T) {
+	dir := useSandbox(t)
+	path := filepath.Join(dir, "profiles.yaml")
+	a := &App{}
+
+	for _, content := range []string{"v1", "v2", "v3", "v4"} {
+		if result := a.WriteFile(path, content, IOOptions{Mode: Text, Atomic: true, Backups: 2}); !result.Flag {
+			t.Fatalf("WriteFile: %s", result.Data)
+		}
+	}
+
+	want := map[string]string{path: "v4", path + ".bak.1": "v3", path + ".bak.2": "v2"}
+	for name, content := range want {
+		if b, _ := os.ReadFile(name); string(b) != content {
+			t.Errorf("%s = %q, want %q", filepath.Base(name), b, content)
+		}
+	}
+	if _, err := os.Stat(path + ".bak.3"); err == nil {
+		t.Error("more backups than requested were kept")
+	}
+
+	entries, _ := os.ReadDir(dir)
+	if len(entries) != 3 {
+		t.Errorf("temporary files were left behind: %v", entries)
+	}
+}
@@ -0,0 +1,243 @@
+package bridge
+
+import (
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"io"
+	"log"
+	"os"
+	"strconv"
+	"sync"
+)
+
+// rotatingLogWriter writes process output to a log file and rotates it once it
+// grows past maxSize. Backups are named path.1, path.2, ... with path.1 being the
+// most recent one. When compress is set, path.1 is gzipped in the background right
+// after the rotation, tailAndEmitLogFile having read the segment before it moved.
+type rotatingLogWriter struct {
+	mu           sync.Mutex
+	path         string
+	maxSize      int64
+	maxBackups   int
+	compress     bool
+	file         *os.File
+	size         int64
+	beforeRotate func()
+	compressing  sync.WaitGroup
+}
+
+func useLogRotation(options ExecOptions) bool {
+	return options.LogMaxSize > 0 || options.LogRotateOnStart
+}
+
+func openRotatingLogWriter(path string, options ExecOptions) (*rotatingLogWriter, error) {
+	w := &rotatingLogWriter{
+		path:       path,
+		maxSize:    options.LogMaxSize,
+		maxBackups: options.LogMaxBackups,
+		compress:   options.LogCompress,
+	}
+
+	flag := os.O_CREATE | os.O_WRONLY | os.O_APPEND
+	if options.LogRotateOnStart {
+		if stat, err := os.Stat(path); err == nil && stat.Size() > 0 {
+			if err := w.shiftBackups(); err != nil {
+				log.Printf("Failed to rotate log file %s: %v", path, err)
+			}
+		}
+	} else {
+		flag |= os.O_TRUNC
+	}
+
+	file, err := os.OpenFile(path, flag, 0644)
+	if err != nil {
+		return nil, err
+	}
+
+	stat, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return nil, err
+	}
+
+	w.file = file
+	w.size = stat.Size()
+
+	return w, nil
+}
+
+func (w *rotatingLogWriter) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.file == nil {
+		return 0, os.ErrClosed
+	}
+
+	written := 0
+
+	if w.maxSize > 0 && w.size > 0 && w.size+int64(len(p)) > w.maxSize {
+		// keep whole lines in the current segment where possible
+		if i := bytes.LastIndexByte(p, '\n'); i >= 0 {
+			n, err := w.file.Write(p[:i+1])
+			w.size += int64(n)
+			written += n
+			if err != nil {
+				return written, err
+			}
+			p = p[i+1:]
+		}
+
+		if err := w.rotate(); err != nil {
+			log.Printf("Failed to rotate log file %s: %v", w.path, err)
+		}
+	}
+
+	n, err := w.file.Write(p)
+	w.size += int64(n)
+
+	return written + n, err
+}
+
+// onRotate sets a function that is called before each rotation, while no more output
+// is written, so a reader can finish the current segment however often it rotates
+func (w *rotatingLogWriter) onRotate(fn func()) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	w.beforeRotate = fn
+}
+
+func (w *rotatingLogWriter) Close() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.file == nil {
+		return nil
+	}
+
+	err := w.file.Close()
+	w.file = nil
+	w.compressing.Wait()
+
+	return err
+}
+
+func (w *rotatingLogWriter) rotate() error {
+	if w.beforeRotate != nil {
+		w.beforeRotate()
+	}
+
+	if w.maxBackups <= 0 {
+		if err := w.file.Truncate(0); err != nil {
+			return err
+		}
+		w.size = 0
+		return nil
+	}
+
+	if err := w.file.Close(); err != nil {
+		return err
+	}
+
+	rotateErr := w.shiftBackups()
+
+	// on failure (e.g. the file is locked by a reader on Windows) keep appending to the current file
+	file, err := os.OpenFile(w.path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		w.file = nil
+		return errors.Join(rotateErr, err)
+	}
+
+	stat, err := file.Stat()
+	if err != nil {
+		file.Close()
+		w.file = nil
+		return errors.Join(rotateErr, err)
+	}
+
+	w.file = file
+	w.size = stat.Size()
+
+	return rotateErr
+}
+
+// shiftBackups moves path to path.1, path.1 to path.2 and so on, dropping the oldest backup
+func (w *rotatingLogWriter) shiftBackups() error {
+	// the backup still being compressed must not be moved from under it
+	w.compressing.Wait()
+
+	if w.maxBackups <= 0 {
+		return os.Remove(w.path)
+	}
+
+	for _, ext := range []string{"", ".gz"} {
+		_ = os.Remove(w.backupPath(w.maxBackups) + ext)
+	}
+
+	for i := w.maxBackups - 1; i >= 1; i-- {
+		for _, ext := range []string{"", ".gz"} {
+			src := w.backupPath(i) + ext
+			if _, err := os.Stat(src); err != nil {
+				continue
+			}
+			if err := os.Rename(src, w.backupPath(i+1)+ext); err != nil {
+				return err
+			}
+		}
+	}
+
+	backup := w.backupPath(1)
+	if err := os.Rename(w.path, backup); err != nil {
+		return err
+	}
+
+	if w.compress {
+		w.compressing.Go(func() {
+			if err := gzipFile(backup, backup+".gz"); err != nil {
+				log.Printf("Failed to compress log file %s: %v", backup, err)
+			}
+		})
+	}
+
+	return nil
+}
+
+func (w *rotatingLogWriter) backupPath(index int) string {
+	return w.path + "." + strconv.Itoa(index)
+}
+
+func gzipFile(src string, dst string) error {
+	srcFile, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer srcFile.Close()
+
+	dstFile, err := os.Create(dst)
+	if err != nil {
+		return err
+	}
+
+	gzipWriter := gzip.NewWriter(dstFile)
+	if _, err := io.Copy(gzipWriter, srcFile); err != nil {
+		gzipWriter.Close()
+		dstFile.Close()
+		_ = os.Remove(dst)
+		return err
+	}
+	if err := gzipWriter.Close(); err != nil {
+		dstFile.Close()
+		_ = os.Remove(dst)
+		return err
+	}
+	if err := dstFile.Close(); err != nil {
+		_ = os.Remove(dst)
+		return err
+	}
+
+	srcFile.Close()
+
+	return os.Remove(src)
+}
@@ -0,0 +1,151 @@
+package bridge
+
+import (
+	"compress/gzip"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+func TestRotatingLogWriter(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "core.log")
+
+	w, err := openRotatingLogWriter(path, ExecOptions{LogMaxSize: 20, LogMaxBackups: 2, LogCompress: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for i := range 4 {
+		fmt.Fprintf(w, "segment %d line\n", i)
+	}
+	w.Close()
+
+	// a write that crosses maxSize still completes its lines before the rotation
+	want := map[string]string{
+		path:           "",
+		path + ".1.gz": "segment 2 line\nsegment 3 line\n",
+		path + ".2.gz": "segment 0 line\nsegment 1 line\n",
+	}
+	for name, content := range want {
+		if got := readLogFile(t, name); got != content {
+			t.Errorf("%s = %q, want %q", filepath.Base(name), got, content)
+		}
+	}
+
+	for _, name := range []string{".1", ".2", ".3.gz"} {
+		if _, err := os.Stat(path + name); err == nil {
+			t.Errorf("%s was kept", filepath.Base(path+name))
+		}
+	}
+}
+
+func TestRotatingLogWriterCompressesSingleBackup(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "core.log")
+
+	w, err := openRotatingLogWriter(path, ExecOptions{LogMaxSize: 10, LogMaxBackups: 1, LogCompress: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// each write past LogMaxSize ends a segment
+	for _, line := range []string{"first\n", "second\n", "third\n", "fourth\n", "fifth"} {
+		io.WriteString(w, line)
+	}
+	w.Close()
+
+	if got := readLogFile(t, path+".1.gz"); got != "third\nfourth\n" {
+		t.Errorf("backup = %q", got)
+	}
+	if got := readLogFile(t, path); got != "fifth" {
+		t.Errorf("current = %q", got)
+	}
+	if _, err := os.Stat(path + ".1"); err == nil {
+		t.Error("the backup was left uncompressed")
+	}
+}
+
+func TestRotatingLogWriterKeepsWholeLines(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "core.log")
+
+	w, err := openRotatingLogWriter(path, ExecOptions{LogMaxSize: 10, LogMaxBackups: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	io.WriteString(w, "first\n")
+	io.WriteString(w, "second\nthi")
+	io.WriteString(w, "rd\n")
+	w.Close()
+
+	if got := readLogFile(t, path+".1"); got != "first\nsecond\n" {
+		t.Errorf("backup = %q", got)
+	}
+	if got := readLogFile(t, path); got != "third\n" {
+		t.Errorf("current = %q", got)
+	}
+}
+
+// Output written across many rotations between two polls of the tail must all be seen,
+// including segments that were compressed in the meantime
+func TestTailLogFileAcrossRotations(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("needs sh")
+	}
+
+	dir := t.TempDir()
+	Env.BasePath = dir
+
+	const lines = 3000
+	script := fmt.Sprintf("i=1; while [ $i -le %d ]; do echo line $i; i=$((i+1)); done", lines)
+
+	proc, err := startBackgroundProcess(&App{}, "sh", []string{"-c", script}, "", ExecOptions{
+		LogFile:           "core.log",
+		LogMaxSize:        512,
+		LogMaxBackups:     2,
+		LogCompress:       true,
+		OutputBufferLines: lines,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := proc.wait(); err != nil {
+		t.Fatal(err)
+	}
+
+	output := proc.entry.output.since(0)
+	if len(output.Lines) != lines {
+		t.Fatalf("got %d lines, want %d", len(output.Lines), lines)
+	}
+	for i, line := range output.Lines {
+		if want := fmt.Sprintf("line %d", i+1); line.Line != want {
+			t.Fatalf("line %d = %v, want %q", i, line.Line, want)
+		}
+	}
+}
+
+func readLogFile(t *testing.T, path string) string {
+	t.Helper()
+
+	file, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+
+	var reader io.Reader = file
+	if strings.HasSuffix(path, ".gz") {
+		gz, err := gzip.NewReader(file)
+		if err != nil {
+			t.Fatal(err)
+		}
+		reader = gz
+	}
+
+	b, err := io.ReadAll(reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(b)
+}
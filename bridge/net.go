@@ -3,7 +3,6 @@ package bridge
 import (
 	"bufio"
 	"context"
-	"crypto/sha256"
 	"fmt"
 	"hash"
 	"io"
@@ -32,6 +31,18 @@ func (a *App) Requests(method string, url string, headers map[string]string, bod
 		headerTimeout = time.AfterFunc(requestTimeout(options.Timeout), cancel)
 	}
 
+	if options.Stream != "" {
+		ctx = withRetryNotify(ctx, func(attempt RequestAttempt) {
+			runtime.EventsEmit(a.Ctx, options.Stream, map[string]any{
+				"type":     "retry",
+				"attempt":  attempt.Attempt,
+				"attempts": attempt.Attempts,
+				"delay":    attempt.Delay,
+				"error":    attempt.Error,
+			})
+		})
+	}
+
 	req, err := http.NewRequestWithContext(ctx, method, url, strings.NewReader(body))
 	if err != nil {
 		return HTTPResult{false, 500, nil, err.Error()}
@@ -42,6 +53,15 @@ func (a *App) Requests(method string, url string, headers map[string]string, bod
 		req.Header.Set("Accept", "text/event-stream")
 	}
 
+	var cacheKey string
+	var cached *httpCacheEntry
+	if options.Cache && method == http.MethodGet && options.Stream == "" {
+		cacheKey = httpCacheKey(url, req.Header)
+		if cached = loadHTTPCache(cacheKey); cached != nil {
+			cached.setConditional(req)
+		}
+	}
+
 	if options.CancelId != "" {
 		runtime.EventsOn(a.Ctx, options.CancelId, func(data ...any) {
 			log.Printf("Requests Canceled: %v %v", method, url)
@@ -163,11 +183,21 @@ func (a *App) Requests(method string, url string, headers map[string]string, bod
 		defer bodyTimeout.Stop()
 	}
 
+	// the cached body is returned along with the 304 status, which tells the caller it
+	// has not changed since the last request
+	if cached != nil && resp.StatusCode == http.StatusNotModified {
+		return HTTPResult{true, resp.StatusCode, cached.notModifiedHeader(resp), cached.Body}
+	}
+
 	b, err := io.ReadAll(resp.Body)
 	if err != nil {
 		return HTTPResult{false, 500, nil, err.Error()}
 	}
 
+	if cacheKey != "" && resp.StatusCode == http.StatusOK {
+		storeHTTPCache(cacheKey, httpCacheEntry{URL: url, Header: resp.Header, Body: string(b)}, resp)
+	}
+
 	return HTTPResult{true, resp.StatusCode, resp.Header, string(b)}
 }
 
@@ -255,18 +285,35 @@ func (a *App) UdpRequest(address string, payload string, options NetOptions) Fla
 	return FlagResult{true, netPayloadString(buf[:n], options)}
 }
 
+// Download writes to path.part and only moves it into place once it is complete and the
+// checksum, if any, matches. An interrupted GET download is resumed from the partial file
+// on the next call when the server supports ranges and the resource has not changed.
+// With options.Segments above 1 a fresh download of a large enough file is fetched by
+// that many concurrent range requests instead. With options.Cache an existing file that
+// was downloaded from the same URL before is revalidated, and left as it is when the
+// server answers 304 Not Modified.
 func (a *App) Download(method string, url string, path string, headers map[string]string, event string, options RequestOptions) HTTPResult {
 	log.Printf("Download: %s %s %s %v %s %v", method, url, path, headers, event, options)
 
-	client, ctx, cancel := withRequestOptionsClient(options)
-	defer cancel()
+	algo, expected, err := requestChecksum(options)
+	if err != nil {
+		return HTTPResult{false, 500, nil, err.Error()}
+	}
 
-	req, err := http.NewRequestWithContext(ctx, method, url, nil)
+	path, err = sandboxPath(path, options.Token)
 	if err != nil {
 		return HTTPResult{false, 500, nil, err.Error()}
 	}
 
-	req.Header = requestHeaders(headers)
+	err = os.MkdirAll(filepath.Dir(path), os.ModePerm)
+	if err != nil {
+		return HTTPResult{false, 500, nil, err.Error()}
+	}
+
+	client, ctx, cancel := withRequestOptionsClient(options)
+	defer cancel()
+
+	ctx = withRetryEvent(ctx, options.RetryEvent, a)
 
 	if options.CancelId != "" {
 		runtime.EventsOn(a.Ctx, options.CancelId, func(data ...any) {
@@ -276,48 +323,116 @@ func (a *App) Download(method string, url string, path string, headers map[strin
 		defer runtime.EventsOff(a.Ctx, options.CancelId)
 	}
 
-	resp, err := client.Do(req)
-	if err != nil {
-		return HTTPResult{false, 500, nil, err.Error()}
+	part := newPartialDownload(path, url)
+
+	newRequest := func() (*http.Request, error) {
+		req, err := http.NewRequestWithContext(ctx, method, url, nil)
+		if err != nil {
+			return nil, err
+		}
+		req.Header = requestHeaders(headers)
+		return req, nil
 	}
-	defer resp.Body.Close()
 
-	path = resolvePath(path)
+	var cacheKey string
+	var cached *httpCacheEntry
+	if options.Cache && method == http.MethodGet {
+		cacheKey = httpCacheKey(url, requestHeaders(headers)) + "\n" + path
+		// the file may have been removed or replaced since it was downloaded
+		if _, err := os.Stat(path); err == nil {
+			cached = loadHTTPCache(cacheKey)
+		}
+	}
 
-	err = os.MkdirAll(filepath.Dir(path), os.ModePerm)
-	if err != nil {
-		return HTTPResult{false, 500, nil, err.Error()}
+	// only the first request is conditional, segments must always get their range
+	firstRequest := func() (*http.Request, error) {
+		req, err := newRequest()
+		if err == nil && cached != nil {
+			cached.setConditional(req)
+		}
+		return req, err
 	}
 
-	file, err := os.Create(path)
+	resp, offset, err := part.request(client, firstRequest)
 	if err != nil {
 		return HTTPResult{false, 500, nil, err.Error()}
 	}
+	defer resp.Body.Close()
+
+	if cached != nil && resp.StatusCode == http.StatusNotModified {
+		part.discard()
+		return HTTPResult{true, resp.StatusCode, resp.Header, "Success"}
+	}
+
+	var actual string
+
+	if ranges := segmentRanges(resp, offset, options.Segments); ranges != nil {
+		// a failed segment cancels the others through ctx
+		progress := newProgressTracker(0, resp.ContentLength, event, a)
+		if err := part.fetchSegments(client, newRequest, resp, ranges, progress, cancel); err != nil {
+			part.discard()
+			return HTTPResult{false, 500, nil, err.Error()}
+		}
+
+		if algo != "" {
+			hash, _ := newHash(algo)
+			if err := part.hashExisting(hash); err != nil {
+				part.discard()
+				return HTTPResult{false, 500, nil, err.Error()}
+			}
+			actual = fmt.Sprintf("%x", hash.Sum(nil))
+		}
+	} else {
+		file, err := part.open(resp, offset)
+		if err != nil {
+			return HTTPResult{false, 500, nil, err.Error()}
+		}
 
-	reader := wrapWithProgress(resp.Body, resp.ContentLength, event, a)
-	writer := io.Writer(file)
+		writer := io.Writer(file)
 
-	var hash hash.Hash
-	if options.Sha256 != "" {
-		hash = sha256.New()
-		writer = io.MultiWriter(file, hash)
+		var hash hash.Hash
+		if algo != "" {
+			hash, _ = newHash(algo)
+			if offset > 0 {
+				if err := part.hashExisting(hash); err != nil {
+					file.Close()
+					return HTTPResult{false, 500, nil, err.Error()}
+				}
+			}
+			writer = io.MultiWriter(file, hash)
+		}
+
+		total := resp.ContentLength
+		if total >= 0 {
+			total += offset
+		}
+		reader := wrapWithProgressFrom(resp.Body, offset, total, event, a)
+
+		_, err = io.Copy(writer, reader)
+		if err != nil {
+			file.Close()
+			return HTTPResult{false, 500, nil, err.Error()}
+		}
+		if err := file.Close(); err != nil {
+			return HTTPResult{false, 500, nil, err.Error()}
+		}
+
+		if algo != "" {
+			actual = fmt.Sprintf("%x", hash.Sum(nil))
+		}
 	}
 
-	_, err = io.Copy(writer, reader)
-	if err != nil {
-		file.Close()
-		return HTTPResult{false, 500, nil, err.Error()}
+	if algo != "" && actual != expected {
+		part.discard()
+		return HTTPResult{false, 500, nil, fmt.Sprintf("%s mismatch: %s, expected %s, got %s", strings.ToUpper(algo), filepath.Base(path), expected, actual)}
 	}
-	if err := file.Close(); err != nil {
+
+	if err := part.commit(); err != nil {
 		return HTTPResult{false, 500, nil, err.Error()}
 	}
 
-	if options.Sha256 != "" {
-		actual := fmt.Sprintf("%x", hash.Sum(nil))
-		if actual != options.Sha256 {
-			_ = os.Remove(path)
-			return HTTPResult{false, 500, nil, fmt.Sprintf("SHA256 mismatch: %s, expected %s, got %s", filepath.Base(path), options.Sha256, actual)}
-		}
+	if cacheKey != "" {
+		storeHTTPCache(cacheKey, httpCacheEntry{URL: url, Path: path}, resp)
 	}
 
 	return HTTPResult{true, resp.StatusCode, resp.Header, "Success"}
@@ -326,44 +441,63 @@ func (a *App) Download(method string, url string, path string, headers map[strin
 func (a *App) Upload(method string, url string, path string, headers map[string]string, event string, options RequestOptions) HTTPResult {
 	log.Printf("Upload: %s %s %s %v %s %v", method, url, path, headers, event, options)
 
-	path = resolvePath(path)
-
-	file, err := os.Open(path)
+	path, err := sandboxPath(path, options.Token)
 	if err != nil {
 		return HTTPResult{false, 500, nil, err.Error()}
 	}
 
-	fileStat, err := file.Stat()
+	fileStat, err := os.Stat(path)
 	if err != nil {
-		file.Close()
 		return HTTPResult{false, 500, nil, err.Error()}
 	}
 
-	bodyReader, bodyWriter := io.Pipe()
-	writer := multipart.NewWriter(bodyWriter)
-	copyErr := make(chan error, 1)
+	boundary := multipart.NewWriter(nil).Boundary()
+	var copyErr chan error
 
-	go func() {
-		defer file.Close()
-
-		part, err := writer.CreateFormFile(options.FileField, filepath.Base(path))
-		if err == nil {
-			_, err = io.Copy(part, wrapWithProgress(file, fileStat.Size(), event, a))
-		}
-		if closeErr := writer.Close(); err == nil {
-			err = closeErr
-		}
+	// every attempt streams the file again, so retries can replay the body
+	newBody := func() (io.ReadCloser, error) {
+		file, err := os.Open(path)
 		if err != nil {
-			_ = bodyWriter.CloseWithError(err)
-		} else {
-			_ = bodyWriter.Close()
+			return nil, err
 		}
-		copyErr <- err
-	}()
+
+		bodyReader, bodyWriter := io.Pipe()
+		writer := multipart.NewWriter(bodyWriter)
+		_ = writer.SetBoundary(boundary)
+		errCh := make(chan error, 1)
+		copyErr = errCh
+
+		go func() {
+			defer file.Close()
+
+			part, err := writer.CreateFormFile(options.FileField, filepath.Base(path))
+			if err == nil {
+				_, err = io.Copy(part, wrapWithProgress(file, fileStat.Size(), event, a))
+			}
+			if closeErr := writer.Close(); err == nil {
+				err = closeErr
+			}
+			if err != nil {
+				_ = bodyWriter.CloseWithError(err)
+			} else {
+				_ = bodyWriter.Close()
+			}
+			errCh <- err
+		}()
+
+		return bodyReader, nil
+	}
+
+	body, err := newBody()
+	if err != nil {
+		return HTTPResult{false, 500, nil, err.Error()}
+	}
 
 	client, ctx, cancel := withRequestOptionsClient(options)
 	defer cancel()
 
+	ctx = withRetryEvent(ctx, options.RetryEvent, a)
+
 	if options.CancelId != "" {
 		runtime.EventsOn(a.Ctx, options.CancelId, func(data ...any) {
 			log.Printf("Upload Canceled: %v %v", url, path)
@@ -372,20 +506,19 @@ func (a *App) Upload(method string, url string, path string, headers map[string]
 		defer runtime.EventsOff(a.Ctx, options.CancelId)
 	}
 
-	req, err := http.NewRequestWithContext(ctx, method, url, bodyReader)
+	req, err := http.NewRequestWithContext(ctx, method, url, body)
 	if err != nil {
-		_ = bodyReader.CloseWithError(err)
+		_ = body.Close()
 		<-copyErr
 		return HTTPResult{false, 500, nil, err.Error()}
 	}
 
+	req.GetBody = newBody
 	req.Header = requestHeaders(headers)
-	req.Header.Set("Content-Type", writer.FormDataContentType())
+	req.Header.Set("Content-Type", "multipart/form-data; boundary="+boundary)
 
 	resp, err := client.Do(req)
 	if err != nil {
-		_ = bodyReader.CloseWithError(err)
-		<-copyErr
 		return HTTPResult{false, 500, nil, err.Error()}
 	}
 	defer resp.Body.Close()
@@ -403,6 +536,9 @@ func (a *App) Upload(method string, url string, path string, headers map[string]
 }
 
 func (wt *WriteTracker) Write(p []byte) (n int, err error) {
+	wt.mu.Lock()
+	defer wt.mu.Unlock()
+
 	n = len(p)
 	wt.Progress += int64(n)
 
@@ -416,21 +552,54 @@ func (wt *WriteTracker) Write(p []byte) (n int, err error) {
 }
 
 func wrapWithProgress(r io.Reader, size int64, event string, a *App) io.Reader {
+	return wrapWithProgressFrom(r, 0, size, event, a)
+}
+
+// wrapWithProgressFrom reports progress starting at offset, for transfers that continue
+// earlier partial ones
+func wrapWithProgressFrom(r io.Reader, offset int64, size int64, event string, a *App) io.Reader {
 	if event == "" {
 		return r
 	}
-	return io.TeeReader(r, &WriteTracker{
+	return io.TeeReader(r, newProgressTracker(offset, size, event, a))
+}
+
+// withRetryEvent reports retries on their own event, kept apart from the progress event
+// so progress handlers only ever see progress
+func withRetryEvent(ctx context.Context, event string, a *App) context.Context {
+	if event == "" {
+		return ctx
+	}
+	return withRetryNotify(ctx, func(attempt RequestAttempt) {
+		runtime.EventsEmit(a.Ctx, event, attempt)
+	})
+}
+
+// newProgressTracker returns a tracker that can be shared by concurrent readers of one
+// transfer, or io.Discard when no event is wanted
+func newProgressTracker(offset int64, size int64, event string, a *App) io.Writer {
+	if event == "" {
+		return io.Discard
+	}
+	return &WriteTracker{
 		Total:          size,
+		Progress:       offset,
+		LastEmitted:    offset,
 		EmitThreshold:  128 * 1024,
 		ProgressChange: event,
 		App:            a,
-	})
+	}
 }
 
 func withRequestOptionsClient(options RequestOptions) (*http.Client, context.Context, context.CancelFunc) {
+	var transport http.RoundTripper = requestTransport(options)
+	if options.Retries > 0 {
+		transport = newRetryTransport(transport, options)
+	}
+
 	client := &http.Client{
 		Timeout:   requestTimeout(options.Timeout),
-		Transport: requestTransport(options),
+		Transport: transport,
 		CheckRedirect: func(req *http.Request, via []*http.Request) error {
 			if !options.Redirect {
 				return http.ErrUseLastResponse
@@ -0,0 +1,374 @@
+package bridge
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/wailsapp/wails/v2/pkg/runtime"
+)
+
+const (
+	OutputParserLogfmt = "Logfmt"
+	OutputParserJSON   = "JSON"
+	OutputParserAuto   = "Auto"
+
+	OutputDropBlock  = "Block"
+	OutputDropOldest = "Oldest"
+	OutputDropNewest = "Newest"
+)
+
+var logLevels = map[string]int{
+	"trace":   0,
+	"debug":   1,
+	"info":    2,
+	"warn":    3,
+	"warning": 3,
+	"error":   4,
+	"fatal":   5,
+	"panic":   5,
+}
+
+// outputEmitter delivers process output to the frontend. When OutputBatchInterval
+// is set, lines are queued and emitted as arrays at most once per interval.
+type outputEmitter struct {
+	send     func(data any)
+	event    string
+	output   *outputRing
+	parser   string
+	minLevel int
+
+	interval   time.Duration
+	batchSize  int
+	dropPolicy string
+	mu         sync.Mutex
+	cond       *sync.Cond
+	queue      []any
+	dropped    int
+	closed     bool
+	stop       chan struct{}
+	flushDone  chan struct{}
+
+	eventsStopped atomic.Bool
+}
+
+// outputRing keeps the last lines of a process output, each tagged with a sequence number
+type outputRing struct {
+	mu    sync.Mutex
+	lines []OutputLine
+	next  int
+	seq   int64
+}
+
+func newOutputEmitter(a *App, event string, output *outputRing, options ExecOptions) *outputEmitter {
+	return startOutputEmitter(event, output, options, func(data any) {
+		runtime.EventsEmit(a.Ctx, event, data)
+	})
+}
+
+// startOutputEmitter creates an emitter handing each line, or each batch of lines, to send
+func startOutputEmitter(event string, output *outputRing, options ExecOptions, send func(data any)) *outputEmitter {
+	e := &outputEmitter{
+		send:     send,
+		event:    event,
+		output:   output,
+		parser:   options.OutputParser,
+		minLevel: logLevels[strings.ToLower(options.OutputLevel)],
+	}
+
+	if options.OutputBatchInterval > 0 {
+		e.interval = time.Duration(options.OutputBatchInterval) * time.Millisecond
+		e.batchSize = options.OutputBatchSize
+		if e.batchSize <= 0 {
+			e.batchSize = 500
+		}
+		e.dropPolicy = options.OutputDropPolicy
+		e.cond = sync.NewCond(&e.mu)
+		e.stop = make(chan struct{})
+		e.flushDone = make(chan struct{})
+		go e.flushLoop()
+	}
+
+	return e
+}
+
+func (e *outputEmitter) emit(text string) {
+	var line any = text
+
+	if e.parser != "" {
+		entry := parseLogLine(text, e.parser)
+
+		// lines without a recognizable level (e.g. panic traces) are never filtered out
+		if level, ok := logLevels[entry.Level]; ok && level < e.minLevel {
+			return
+		}
+
+		line = entry
+	}
+
+	if e.output != nil {
+		e.output.add(line)
+	}
+
+	if e.event == "" || e.eventsStopped.Load() {
+		return
+	}
+
+	if e.interval <= 0 {
+		e.send(line)
+		return
+	}
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if e.dropPolicy != OutputDropOldest && e.dropPolicy != OutputDropNewest {
+		for len(e.queue) >= e.batchSize && !e.closed {
+			e.cond.Wait()
+		}
+	}
+
+	if len(e.queue) >= e.batchSize {
+		e.dropped++
+		if e.dropPolicy == OutputDropNewest {
+			return
+		}
+		e.queue = e.queue[1:]
+	}
+
+	e.queue = append(e.queue, line)
+}
+
+// close flushes the remaining lines, it must be called once the output source is exhausted
+func (e *outputEmitter) close() {
+	if e.interval <= 0 {
+		return
+	}
+
+	e.mu.Lock()
+	e.closed = true
+	e.cond.Broadcast()
+	e.mu.Unlock()
+
+	close(e.stop)
+	<-e.flushDone
+}
+
+func (e *outputEmitter) flushLoop() {
+	defer close(e.flushDone)
+
+	ticker := time.NewTicker(e.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			e.flush()
+		case <-e.stop:
+			e.flush()
+			return
+		}
+	}
+}
+
+func (e *outputEmitter) flush() {
+	e.mu.Lock()
+	batch := e.queue
+	dropped := e.dropped
+	e.queue = nil
+	e.dropped = 0
+	e.cond.Broadcast()
+	e.mu.Unlock()
+
+	if dropped > 0 {
+		marker := fmt.Sprintf("[%d lines dropped]", dropped)
+		if e.parser != "" {
+			batch = append([]any{LogEntry{Level: "warning", Message: marker, Raw: marker}}, batch...)
+		} else {
+			batch = append([]any{marker}, batch...)
+		}
+	}
+
+	if len(batch) > 0 {
+		e.send(batch)
+	}
+}
+
+// stopEvents ends the event stream, e.g. once StopOutputKeyword was seen, while lines
+// are still recorded into the output ring
+func (e *outputEmitter) stopEvents() {
+	e.eventsStopped.Store(true)
+}
+
+func newOutputRing(size int) *outputRing {
+	return &outputRing{lines: make([]OutputLine, 0, size)}
+}
+
+// continueOutputRing returns the ring for a new run of a process with the same id. The
+// lines and sequence numbers of prev are kept, so pollers of GetProcessOutput do not
+// lose their position when a supervised process is restarted.
+func continueOutputRing(prev *outputRing, size int) *outputRing {
+	if prev == nil {
+		return newOutputRing(size)
+	}
+
+	prev.mu.Lock()
+	defer prev.mu.Unlock()
+
+	if cap(prev.lines) == size {
+		return prev
+	}
+
+	ring := newOutputRing(size)
+	ring.seq = prev.seq
+	for i := range prev.lines {
+		item := prev.lines[(prev.next+i)%len(prev.lines)]
+		if len(ring.lines) < size {
+			ring.lines = append(ring.lines, item)
+		} else {
+			ring.lines[ring.next] = item
+			ring.next = (ring.next + 1) % size
+		}
+	}
+
+	return ring
+}
+
+func (r *outputRing) add(line any) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.seq++
+	item := OutputLine{Seq: r.seq, Line: line}
+
+	if len(r.lines) < cap(r.lines) {
+		r.lines = append(r.lines, item)
+		return
+	}
+
+	r.lines[r.next] = item
+	r.next = (r.next + 1) % len(r.lines)
+}
+
+// since returns the buffered lines with a sequence number greater than seq
+func (r *outputRing) since(seq int64) ProcessOutput {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	result := ProcessOutput{Lines: []OutputLine{}, LastSeq: r.seq}
+
+	for i := range r.lines {
+		item := r.lines[(r.next+i)%len(r.lines)]
+		if item.Seq > seq {
+			result.Lines = append(result.Lines, item)
+		}
+	}
+
+	// lines between seq and the oldest buffered one have been overwritten
+	if len(r.lines) > 0 {
+		oldest := r.lines[r.next%len(r.lines)].Seq
+		result.Truncated = seq+1 < oldest
+	}
+
+	return result
+}
+
+func parseLogLine(text string, parser string) LogEntry {
+	entry := LogEntry{Message: text, Raw: text}
+
+	trimmed := strings.TrimSpace(text)
+	isJSON := parser == OutputParserJSON || (parser == OutputParserAuto && strings.HasPrefix(trimmed, "{"))
+
+	var fields map[string]any
+	if isJSON {
+		if err := json.Unmarshal([]byte(trimmed), &fields); err != nil {
+			return entry
+		}
+	} else {
+		fields = parseLogfmt(trimmed)
+		if fields == nil {
+			return entry
+		}
+	}
+
+	take := func(keys ...string) string {
+		for _, key := range keys {
+			if value, ok := fields[key]; ok {
+				delete(fields, key)
+				if s, ok := value.(string); ok {
+					return s
+				}
+				b, _ := json.Marshal(value)
+				return string(b)
+			}
+		}
+		return ""
+	}
+
+	entry.Time = take("time", "ts", "timestamp")
+	entry.Level = strings.ToLower(take("level", "lvl"))
+	if msg := take("msg", "message"); msg != "" || entry.Level != "" {
+		entry.Message = msg
+	}
+	if len(fields) > 0 {
+		entry.Fields = fields
+	}
+
+	return entry
+}
+
+// parseLogfmt parses `key=value key="quoted value"` pairs, returning nil if text is not logfmt
+func parseLogfmt(text string) map[string]any {
+	fields := make(map[string]any)
+
+	for text != "" {
+		text = strings.TrimLeft(text, " ")
+
+		eq := strings.IndexByte(text, '=')
+		if eq <= 0 || strings.ContainsAny(text[:eq], " \"") {
+			return nil
+		}
+		key := text[:eq]
+		text = text[eq+1:]
+
+		var value string
+		if strings.HasPrefix(text, `"`) {
+			end := closingQuote(text)
+			if end < 0 {
+				return nil
+			}
+			unquoted, err := strconv.Unquote(text[:end+1])
+			if err != nil {
+				return nil
+			}
+			value = unquoted
+			text = text[end+1:]
+		} else {
+			value, text, _ = strings.Cut(text, " ")
+		}
+
+		fields[key] = value
+	}
+
+	if len(fields) == 0 {
+		return nil
+	}
+
+	return fields
+}
+
+func closingQuote(text string) int {
+	for i := 1; i < len(text); i++ {
+		switch text[i] {
+		case '\\':
+			i++
+		case '"':
+			return i
+		}
+	}
+	return -1
+}
@@ -0,0 +1,344 @@
+package bridge
+
+import (
+	"fmt"
+	"reflect"
+	"runtime"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestParseLogfmt(t *testing.T) {
+	tests := []struct {
+		text string
+		want map[string]any
+	}{
+		{`time="2024-01-01T00:00:00Z" level=info msg="[TCP] dial ok"`, map[string]any{"time": "2024-01-01T00:00:00Z", "level": "info", "msg": "[TCP] dial ok"}},
+		{`level=warning msg="quoted \"inner\" value" port=7890`, map[string]any{"level": "warning", "msg": `quoted "inner" value`, "port": "7890"}},
+		{`key=`, map[string]any{"key": ""}},
+		{`plain text line`, nil},
+		{`msg="unterminated`, nil},
+		{`=value`, nil},
+		{``, nil},
+	}
+
+	for _, tt := range tests {
+		if got := parseLogfmt(tt.text); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("parseLogfmt(%q) = %v, want %v", tt.text, got, tt.want)
+		}
+	}
+}
+
+func TestParseLogLine(t *testing.T) {
+	tests := []struct {
+		name   string
+		text   string
+		parser string
+		want   LogEntry
+	}{
+		{
+			"logfmt",
+			`time=12:00 level=INFO msg=started port=7890`,
+			OutputParserLogfmt,
+			LogEntry{Level: "info", Time: "12:00", Message: "started", Fields: map[string]any{"port": "7890"}},
+		},
+		{
+			"json",
+			`{"level":"error","ts":1.5,"message":"failed","code":3}`,
+			OutputParserJSON,
+			LogEntry{Level: "error", Time: "1.5", Message: "failed", Fields: map[string]any{"code": float64(3)}},
+		},
+		{
+			"auto json",
+			`{"lvl":"debug","msg":"x"}`,
+			OutputParserAuto,
+			LogEntry{Level: "debug", Message: "x"},
+		},
+		{
+			"auto logfmt",
+			`level=warn msg=y`,
+			OutputParserAuto,
+			LogEntry{Level: "warn", Message: "y"},
+		},
+		{
+			"unparsable keeps the raw line",
+			`panic: runtime error`,
+			OutputParserLogfmt,
+			LogEntry{Message: "panic: runtime error"},
+		},
+		{
+			"invalid json",
+			`{not json`,
+			OutputParserJSON,
+			LogEntry{Message: "{not json"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.want.Raw = tt.text
+			if got := parseLogLine(tt.text, tt.parser); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestOutputRingSince(t *testing.T) {
+	ring := newOutputRing(3)
+
+	if out := ring.since(0); len(out.Lines) != 0 || out.LastSeq != 0 || out.Truncated {
+		t.Fatalf("empty ring: %+v", out)
+	}
+
+	for i := 1; i <= 5; i++ {
+		ring.add(fmt.Sprint(i))
+	}
+
+	tests := []struct {
+		since     int64
+		want      []int64
+		truncated bool
+	}{
+		{0, []int64{3, 4, 5}, true},
+		{1, []int64{3, 4, 5}, true},
+		{2, []int64{3, 4, 5}, false},
+		{4, []int64{5}, false},
+		{5, nil, false},
+	}
+
+	for _, tt := range tests {
+		out := ring.since(tt.since)
+		if got := outputSeqs(out); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("since(%d) = %v, want %v", tt.since, got, tt.want)
+		}
+		if out.Truncated != tt.truncated {
+			t.Errorf("since(%d) truncated = %v, want %v", tt.since, out.Truncated, tt.truncated)
+		}
+		if out.LastSeq != 5 {
+			t.Errorf("since(%d) lastSeq = %d, want 5", tt.since, out.LastSeq)
+		}
+	}
+}
+
+func TestContinueOutputRing(t *testing.T) {
+	prev := newOutputRing(4)
+	for i := 1; i <= 6; i++ {
+		prev.add(fmt.Sprint(i))
+	}
+
+	if continueOutputRing(prev, 4) != prev {
+		t.Error("a ring of the same size should be reused")
+	}
+
+	smaller := continueOutputRing(prev, 2)
+	smaller.add("7")
+	if got := outputSeqs(smaller.since(0)); !reflect.DeepEqual(got, []int64{6, 7}) {
+		t.Errorf("resized ring = %v, want [6 7]", got)
+	}
+
+	larger := continueOutputRing(prev, 8)
+	larger.add("7")
+	if got := outputSeqs(larger.since(0)); !reflect.DeepEqual(got, []int64{3, 4, 5, 6, 7}) {
+		t.Errorf("resized ring = %v, want [3 4 5 6 7]", got)
+	}
+}
+
+func TestOutputRecordedAfterStopKeyword(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("needs sh")
+	}
+
+	Env.BasePath = t.TempDir()
+	script := "echo before; echo READY; sleep 0.3; echo after"
+
+	for _, logFile := range []string{"", "core.log"} {
+		t.Run("log file "+logFile, func(t *testing.T) {
+			proc, err := startBackgroundProcess(&App{}, "sh", []string{"-c", script}, "", ExecOptions{
+				LogFile:           logFile,
+				StopOutputKeyword: "READY",
+				OutputBufferLines: 10,
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err := proc.wait(); err != nil {
+				t.Fatal(err)
+			}
+
+			var lines []any
+			for _, line := range proc.entry.output.since(0).Lines {
+				lines = append(lines, line.Line)
+			}
+			if want := []any{"before", "READY", "after"}; !reflect.DeepEqual(lines, want) {
+				t.Errorf("lines = %v, want %v", lines, want)
+			}
+		})
+	}
+}
+
+func TestExecHasNoOutputRing(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("needs sh")
+	}
+
+	a := &App{}
+	a.Exec("sh", []string{"-c", "echo hi"}, ExecOptions{Id: "sync-exec", OutputBufferLines: 10})
+
+	if result := a.GetProcessOutput("sync-exec", 0); result.Flag {
+		t.Errorf("sync Exec should not buffer output: %s", result.Data)
+	}
+}
+
+func TestSupervisedOutputSurvivesRestart(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("needs sh")
+	}
+
+	processMu.Lock()
+	delete(processMap, "restarting")
+	processMu.Unlock()
+
+	a := &App{}
+	options := SupervisorOptions{
+		Exec:           ExecOptions{OutputBufferLines: 10},
+		MaxRestarts:    1,
+		BackoffInitial: 10,
+		BackoffMax:     20,
+	}
+	if result := a.StartSupervised("restarting", "sh", []string{"-c", "echo run; exit 1"}, "", "", options); !result.Flag {
+		t.Fatalf("StartSupervised: %s", result.Data)
+	}
+	defer a.StopSupervised("restarting", 5)
+
+	waitSupervisorState(t, a, "restarting", SupervisorStopped)
+
+	processMu.RLock()
+	entry := processMap["restarting"]
+	processMu.RUnlock()
+
+	// wait for the last run's output to be recorded
+	deadline := time.Now().Add(5 * time.Second)
+	for len(entry.output.since(0).Lines) < 2 && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	if got := outputSeqs(entry.output.since(0)); !reflect.DeepEqual(got, []int64{1, 2}) {
+		t.Errorf("seqs across restarts = %v, want [1 2]", got)
+	}
+}
+
+func outputSeqs(out ProcessOutput) []int64 {
+	var seqs []int64
+	for _, line := range out.Lines {
+		seqs = append(seqs, line.Seq)
+	}
+	return seqs
+}
+
+// emitterBatches starts an emitter that records every batch it sends
+func emitterBatches(options ExecOptions) (*outputEmitter, func() [][]any) {
+	var mu sync.Mutex
+	var batches [][]any
+
+	e := startOutputEmitter("output", nil, options, func(data any) {
+		mu.Lock()
+		defer mu.Unlock()
+		batches = append(batches, data.([]any))
+	})
+
+	return e, func() [][]any {
+		mu.Lock()
+		defer mu.Unlock()
+		return batches
+	}
+}
+
+func TestOutputEmitterDropPolicies(t *testing.T) {
+	tests := []struct {
+		policy string
+		want   []any
+	}{
+		{OutputDropOldest, []any{"[2 lines dropped]", "3", "4", "5"}},
+		{OutputDropNewest, []any{"[2 lines dropped]", "1", "2", "3"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.policy, func(t *testing.T) {
+			// the interval never elapses, only closing flushes
+			e, batches := emitterBatches(ExecOptions{OutputBatchInterval: 3600_000, OutputBatchSize: 3, OutputDropPolicy: tt.policy})
+			for i := 1; i <= 5; i++ {
+				e.emit(fmt.Sprint(i))
+			}
+			if got := batches(); len(got) != 0 {
+				t.Fatalf("sent before the interval: %v", got)
+			}
+
+			e.close()
+			if got := batches(); !reflect.DeepEqual(got, [][]any{tt.want}) {
+				t.Errorf("batches = %v, want [%v]", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestOutputEmitterDroppedMarkerParsed(t *testing.T) {
+	e, batches := emitterBatches(ExecOptions{OutputBatchInterval: 3600_000, OutputBatchSize: 1, OutputDropPolicy: OutputDropOldest, OutputParser: OutputParserLogfmt})
+	e.emit("level=info msg=first")
+	e.emit("level=info msg=second")
+	e.close()
+
+	marker := LogEntry{Level: "warning", Message: "[1 lines dropped]", Raw: "[1 lines dropped]"}
+	got := batches()
+	if len(got) != 1 || len(got[0]) != 2 || !reflect.DeepEqual(got[0][0], marker) || got[0][1].(LogEntry).Message != "second" {
+		t.Errorf("batches = %+v", got)
+	}
+}
+
+func TestOutputEmitterBlocks(t *testing.T) {
+	e, batches := emitterBatches(ExecOptions{OutputBatchInterval: 10, OutputBatchSize: 2, OutputDropPolicy: OutputDropBlock})
+
+	// the writer waits for the queue to drain instead of losing lines
+	const lines = 20
+	start := time.Now()
+	for i := range lines {
+		e.emit(fmt.Sprint(i))
+	}
+	if elapsed := time.Since(start); elapsed < 50*time.Millisecond {
+		t.Errorf("emitting %d lines into batches of 2 did not block, took %v", lines, elapsed)
+	}
+	e.close()
+
+	var all []any
+	for _, batch := range batches() {
+		if len(batch) > 2 {
+			t.Errorf("batch over OutputBatchSize: %v", batch)
+		}
+		all = append(all, batch...)
+	}
+	for i, line := range all {
+		if line != fmt.Sprint(i) {
+			t.Fatalf("lines = %v", all)
+		}
+	}
+	if len(all) != lines {
+		t.Errorf("got %d lines, want %d", len(all), lines)
+	}
+}
+
+func TestOutputEmitterUnbatched(t *testing.T) {
+	var sent []any
+	e := startOutputEmitter("output", nil, ExecOptions{}, func(data any) {
+		sent = append(sent, data)
+	})
+	e.emit("one")
+	e.emit("two")
+	e.stopEvents()
+	e.emit("three")
+	e.close()
+
+	if !reflect.DeepEqual(sent, []any{"one", "two"}) {
+		t.Errorf("sent = %v", sent)
+	}
+}
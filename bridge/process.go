@@ -0,0 +1,323 @@
+package bridge
+
+import (
+	"cmp"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"io"
+	"log"
+	"os"
+	"os/exec"
+	"slices"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+const maxExitedProcesses = 100
+
+var processCounter atomic.Uint64
+
+var (
+	processMu  sync.RWMutex
+	processMap = make(map[string]*processEntry)
+)
+
+type processEntry struct {
+	record  ProcessRecord
+	process *os.Process
+	stdinMu sync.Mutex
+	stdin   io.WriteCloser
+	output  *outputRing
+}
+
+func (a *App) ListProcesses() FlagResult {
+	log.Printf("ListProcesses")
+
+	processMu.RLock()
+	records := make([]ProcessRecord, 0, len(processMap))
+	for _, entry := range processMap {
+		records = append(records, entry.record)
+	}
+	processMu.RUnlock()
+
+	slices.SortFunc(records, func(a, b ProcessRecord) int {
+		return cmp.Compare(a.StartTime, b.StartTime)
+	})
+
+	b, err := json.Marshal(records)
+	if err != nil {
+		return FlagResult{false, err.Error()}
+	}
+
+	return FlagResult{true, string(b)}
+}
+
+func (a *App) GetProcess(id string) FlagResult {
+	log.Printf("GetProcess: %s", id)
+
+	processMu.RLock()
+	entry, ok := processMap[id]
+	var record ProcessRecord
+	if ok {
+		record = entry.record
+	}
+	processMu.RUnlock()
+
+	if !ok {
+		return FlagResult{false, "process not found"}
+	}
+
+	b, err := json.Marshal(record)
+	if err != nil {
+		return FlagResult{false, err.Error()}
+	}
+
+	return FlagResult{true, string(b)}
+}
+
+func (a *App) GetProcessOutput(id string, sinceSeq int64) FlagResult {
+	log.Printf("GetProcessOutput: %s %d", id, sinceSeq)
+
+	processMu.RLock()
+	entry, ok := processMap[id]
+	processMu.RUnlock()
+
+	if !ok {
+		return FlagResult{false, "process not found"}
+	}
+	if entry.output == nil {
+		return FlagResult{false, "output buffering is not enabled for this process"}
+	}
+
+	b, err := json.Marshal(entry.output.since(sinceSeq))
+	if err != nil {
+		return FlagResult{false, err.Error()}
+	}
+
+	return FlagResult{true, string(b)}
+}
+
+func (a *App) WriteProcessStdin(id string, data string, mode string) FlagResult {
+	log.Printf("WriteProcessStdin [%s]: %s", mode, id)
+
+	var b []byte
+	var err error
+
+	switch mode {
+	case Text:
+		b = []byte(data)
+	case Binary:
+		b, err = base64.StdEncoding.DecodeString(data)
+		if err != nil {
+			return FlagResult{false, err.Error()}
+		}
+	default:
+		return FlagResult{false, "Unsupported IO mode: " + mode}
+	}
+
+	entry, err := processStdin(id)
+	if err != nil {
+		return FlagResult{false, err.Error()}
+	}
+
+	entry.stdinMu.Lock()
+	defer entry.stdinMu.Unlock()
+
+	if entry.stdin == nil {
+		return FlagResult{false, "stdin is closed"}
+	}
+
+	if _, err := entry.stdin.Write(b); err != nil {
+		return FlagResult{false, err.Error()}
+	}
+
+	return FlagResult{true, "Success"}
+}
+
+func (a *App) CloseProcessStdin(id string) FlagResult {
+	log.Printf("CloseProcessStdin: %s", id)
+
+	entry, err := processStdin(id)
+	if err != nil {
+		return FlagResult{false, err.Error()}
+	}
+
+	entry.stdinMu.Lock()
+	defer entry.stdinMu.Unlock()
+
+	if entry.stdin == nil {
+		return FlagResult{false, "stdin is closed"}
+	}
+
+	err = entry.stdin.Close()
+	entry.stdin = nil
+	if err != nil {
+		return FlagResult{false, err.Error()}
+	}
+
+	return FlagResult{true, "Success"}
+}
+
+func processStdin(id string) (*processEntry, error) {
+	processMu.RLock()
+	entry, ok := processMap[id]
+	running := ok && entry.record.Running
+	processMu.RUnlock()
+
+	if !ok {
+		return nil, errors.New("process not found")
+	}
+	if !running {
+		return nil, errors.New("process is not running")
+	}
+
+	return entry, nil
+}
+
+// registerProcess reserves an id for a command before it is started
+func registerProcess(cmd *exec.Cmd, background bool, options ExecOptions) (*processEntry, error) {
+	id := options.Id
+	if id == "" {
+		id = strconv.FormatUint(processCounter.Add(1), 10)
+	}
+
+	entry := &processEntry{
+		record: ProcessRecord{
+			Id:               id,
+			Path:             cmd.Path,
+			Args:             cmd.Args[1:],
+			Background:       background,
+			KeepAlive:        options.KeepAlive,
+			WorkingDirectory: cmd.Dir,
+			ExitCode:         -1,
+		},
+	}
+	if options.PidFile != "" {
+		entry.record.PidFile = resolvePath(options.PidFile)
+	}
+	if options.LogFile != "" {
+		entry.record.LogFile = resolvePath(options.LogFile)
+	}
+
+	processMu.Lock()
+	defer processMu.Unlock()
+
+	existing, exists := processMap[id]
+	if exists && (existing.record.Running || existing.record.StartTime == 0) {
+		return nil, errors.New("process already exists: " + id)
+	}
+
+	// only background output can be read back, a synchronous Exec returns all of it
+	if background && options.OutputBufferLines > 0 {
+		var prev *outputRing
+		if exists {
+			prev = existing.output
+		}
+		entry.output = continueOutputRing(prev, options.OutputBufferLines)
+	}
+
+	processMap[id] = entry
+
+	return entry, nil
+}
+
+func (e *processEntry) started(process *os.Process) {
+	processMu.Lock()
+	defer processMu.Unlock()
+
+	e.process = process
+	e.record.Pid = process.Pid
+	e.record.Running = true
+	e.record.StartTime = time.Now().UnixMilli()
+}
+
+func (e *processEntry) exited(state *os.ProcessState, err error) {
+	processMu.Lock()
+	defer processMu.Unlock()
+
+	e.record.Running = false
+	e.record.EndTime = time.Now().UnixMilli()
+	if state != nil {
+		e.record.ExitCode = state.ExitCode()
+	}
+	if err != nil {
+		e.record.Error = err.Error()
+	}
+	evictStatsProcess(int32(e.record.Pid))
+
+	pruneExitedProcesses()
+}
+
+func (e *processEntry) unregister() {
+	processMu.Lock()
+	defer processMu.Unlock()
+
+	if processMap[e.record.Id] == e {
+		delete(processMap, e.record.Id)
+	}
+}
+
+func pruneExitedProcesses() {
+	var exited []*processEntry
+	for _, entry := range processMap {
+		if !entry.record.Running && entry.record.EndTime != 0 {
+			exited = append(exited, entry)
+		}
+	}
+
+	if len(exited) <= maxExitedProcesses {
+		return
+	}
+
+	slices.SortFunc(exited, func(a, b *processEntry) int {
+		return cmp.Compare(a.record.EndTime, b.record.EndTime)
+	})
+
+	for _, entry := range exited[:len(exited)-maxExitedProcesses] {
+		delete(processMap, entry.record.Id)
+	}
+}
+
+// stopAllProcesses gracefully stops every running child that was not started with KeepAlive
+func stopAllProcesses(timeout int) {
+	supervisorMap.Range(func(key, value any) bool {
+		if s, ok := value.(*supervisor); ok && !s.options.Exec.KeepAlive {
+			s.requestStop()
+		}
+		return true
+	})
+
+	processMu.RLock()
+	var processes []*os.Process
+	for _, entry := range processMap {
+		if entry.record.Running && !entry.record.KeepAlive && entry.process != nil {
+			processes = append(processes, entry.process)
+		}
+	}
+	processMu.RUnlock()
+
+	if len(processes) == 0 {
+		return
+	}
+
+	log.Printf("Stopping %d child processes", len(processes))
+
+	for _, p := range processes {
+		if err := SendExitSignal(p); err != nil {
+			log.Printf("SendExitSignal Err: %s", err.Error())
+		}
+	}
+
+	var wg sync.WaitGroup
+	for _, p := range processes {
+		wg.Go(func() {
+			if err := waitForProcessExitWithTimeout(p, timeout); err != nil {
+				log.Printf("Failed to stop process %d: %v", p.Pid, err)
+			}
+		})
+	}
+	wg.Wait()
+}
@@ -0,0 +1,240 @@
+package bridge
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"reflect"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+)
+
+// startTestProcess runs script in the background under id and kills it when the test ends
+func startTestProcess(t *testing.T, a *App, id string, script string, options ExecOptions) {
+	t.Helper()
+
+	options.Id = id
+	if result := a.ExecBackground("sh", []string{"-c", script}, "", "", options); !result.Flag {
+		t.Fatalf("ExecBackground %s: %s", id, result.Data)
+	}
+	t.Cleanup(func() {
+		if record, ok := getProcessRecord(a, id); ok && record.Running {
+			a.KillProcess(record.Pid, 5)
+		}
+		waitProcessExit(t, a, id)
+	})
+}
+
+func getProcessRecord(a *App, id string) (ProcessRecord, bool) {
+	var record ProcessRecord
+	result := a.GetProcess(id)
+	if !result.Flag {
+		return record, false
+	}
+	json.Unmarshal([]byte(result.Data), &record)
+	return record, true
+}
+
+func waitProcessExit(t *testing.T, a *App, id string) ProcessRecord {
+	t.Helper()
+
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		record, _ := getProcessRecord(a, id)
+		if !record.Running {
+			return record
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("%s still running", id)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestDuplicateProcessKeepsLog(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("needs sh")
+	}
+
+	dir := t.TempDir()
+	Env.BasePath = dir
+	a := &App{}
+	logPath := filepath.Join(dir, "core.log")
+
+	startTestProcess(t, a, "dup-core", "echo first; exec sleep 10", ExecOptions{LogFile: "core.log"})
+
+	deadline := time.Now().Add(5 * time.Second)
+	for b, _ := os.ReadFile(logPath); !strings.Contains(string(b), "first"); b, _ = os.ReadFile(logPath) {
+		if time.Now().After(deadline) {
+			t.Fatalf("log = %q", b)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	for _, options := range []ExecOptions{
+		{Id: "dup-core", LogFile: "core.log"},
+		{Id: "dup-core", LogFile: "core.log", LogRotateOnStart: true},
+	} {
+		result := a.ExecBackground("sh", []string{"-c", "echo second"}, "", "", options)
+		if result.Flag || !strings.Contains(result.Data, "process already exists") {
+			t.Fatalf("duplicate id = %+v", result)
+		}
+	}
+
+	if b, _ := os.ReadFile(logPath); string(b) != "first\n" {
+		t.Errorf("log of the running process = %q", b)
+	}
+	if _, err := os.Stat(logPath + ".1"); !os.IsNotExist(err) {
+		t.Errorf("log of the running process was rotated: %v", err)
+	}
+}
+
+func TestListAndGetProcesses(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("needs sh")
+	}
+
+	Env.BasePath = t.TempDir()
+	a := &App{}
+
+	startTestProcess(t, a, "list-first", "exec sleep 10", ExecOptions{})
+	time.Sleep(5 * time.Millisecond)
+	startTestProcess(t, a, "list-second", "exit 3", ExecOptions{KeepAlive: true})
+
+	record := waitProcessExit(t, a, "list-second")
+	if record.ExitCode != 3 || !record.KeepAlive || !record.Background || record.EndTime == 0 {
+		t.Errorf("exited record = %+v", record)
+	}
+
+	record, ok := getProcessRecord(a, "list-first")
+	if !ok || !record.Running || record.Pid == 0 || record.ExitCode != -1 || record.Args[1] != "exec sleep 10" {
+		t.Errorf("running record = %+v", record)
+	}
+
+	if result := a.GetProcess("list-missing"); result.Flag {
+		t.Error("found a process that was never started")
+	}
+
+	result := a.ListProcesses()
+	if !result.Flag {
+		t.Fatal(result.Data)
+	}
+	var records []ProcessRecord
+	if err := json.Unmarshal([]byte(result.Data), &records); err != nil {
+		t.Fatal(err)
+	}
+
+	// sorted by start time
+	first, second := -1, -1
+	for i, record := range records {
+		if i > 0 && record.StartTime < records[i-1].StartTime {
+			t.Errorf("records not sorted by start time: %+v", records)
+		}
+		switch record.Id {
+		case "list-first":
+			first = i
+		case "list-second":
+			second = i
+		}
+	}
+	if first == -1 || second == -1 || first > second {
+		t.Errorf("records = %+v", records)
+	}
+}
+
+func TestStopAllProcessesKeepsKeepAlive(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("needs sh")
+	}
+
+	Env.BasePath = t.TempDir()
+	a := &App{}
+
+	startTestProcess(t, a, "stop-helper", "exec sleep 10", ExecOptions{})
+	startTestProcess(t, a, "stop-core", "exec sleep 10", ExecOptions{KeepAlive: true})
+
+	start := time.Now()
+	stopAllProcesses(5)
+	if elapsed := time.Since(start); elapsed > 4*time.Second {
+		t.Errorf("stopping took %v", elapsed)
+	}
+
+	if record := waitProcessExit(t, a, "stop-helper"); record.EndTime == 0 {
+		t.Errorf("helper = %+v", record)
+	}
+	if record, _ := getProcessRecord(a, "stop-core"); !record.Running {
+		t.Errorf("KeepAlive process was stopped: %+v", record)
+	}
+}
+
+func TestProcessStdin(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("needs sh")
+	}
+
+	Env.BasePath = t.TempDir()
+	a := &App{}
+
+	// output is kept across runs under the same id
+	processMu.Lock()
+	delete(processMap, "stdin-echo")
+	processMu.Unlock()
+
+	script := `while read line; do echo "got $line"; done; echo eof; exec sleep 10`
+	startTestProcess(t, a, "stdin-echo", script, ExecOptions{Stdin: true, OutputBufferLines: 10})
+
+	waitOutput := func(want []any) {
+		t.Helper()
+
+		deadline := time.Now().Add(5 * time.Second)
+		for {
+			var lines []any
+			var output ProcessOutput
+			if result := a.GetProcessOutput("stdin-echo", 0); result.Flag {
+				json.Unmarshal([]byte(result.Data), &output)
+			}
+			for _, line := range output.Lines {
+				lines = append(lines, line.Line)
+			}
+			if reflect.DeepEqual(lines, want) {
+				return
+			}
+			if time.Now().After(deadline) {
+				t.Fatalf("output = %v, want %v", lines, want)
+			}
+			time.Sleep(10 * time.Millisecond)
+		}
+	}
+
+	if result := a.WriteProcessStdin("stdin-echo", "hello\n", Text); !result.Flag {
+		t.Fatal(result.Data)
+	}
+	waitOutput([]any{"got hello"})
+
+	if result := a.WriteProcessStdin("stdin-echo", base64.StdEncoding.EncodeToString([]byte("wörld\n")), Binary); !result.Flag {
+		t.Fatal(result.Data)
+	}
+	if result := a.WriteProcessStdin("stdin-echo", "x", "Hex"); result.Flag {
+		t.Error("accepted an unknown mode")
+	}
+
+	if result := a.CloseProcessStdin("stdin-echo"); !result.Flag {
+		t.Fatal(result.Data)
+	}
+	waitOutput([]any{"got hello", "got wörld", "eof"})
+
+	// the process keeps running with its stdin closed
+	if result := a.WriteProcessStdin("stdin-echo", "late\n", Text); result.Flag || result.Data != "stdin is closed" {
+		t.Errorf("write after close = %+v", result)
+	}
+	if result := a.CloseProcessStdin("stdin-echo"); result.Flag || result.Data != "stdin is closed" {
+		t.Errorf("second close = %+v", result)
+	}
+
+	if result := a.WriteProcessStdin("stdin-missing", "x", Text); result.Flag || result.Data != "process not found" {
+		t.Errorf("unknown process = %+v", result)
+	}
+}
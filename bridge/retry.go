@@ -0,0 +1,156 @@
+package bridge
+
+import (
+	"context"
+	"io"
+	"log"
+	"math/rand/v2"
+	"net/http"
+	"slices"
+	"strconv"
+	"time"
+)
+
+const (
+	defaultRetryBackoff = time.Second
+	maxRetryBackoff     = time.Minute
+	maxRetryAfter       = 10 * time.Minute
+)
+
+var defaultRetryStatus = []int{
+	http.StatusRequestTimeout,
+	http.StatusTooManyRequests,
+	http.StatusInternalServerError,
+	http.StatusBadGateway,
+	http.StatusServiceUnavailable,
+	http.StatusGatewayTimeout,
+}
+
+// retryTransport repeats requests that fail with a network error or one of the retry
+// statuses, waiting with exponential backoff or as long as Retry-After asks. Requests
+// whose body cannot be replayed are sent only once.
+type retryTransport struct {
+	base     http.RoundTripper
+	retries  int
+	backoff  time.Duration
+	statuses []int
+}
+
+type retryNotifyKey struct{}
+
+// withRetryNotify makes requests sent with the returned context call notify before
+// every retry
+func withRetryNotify(ctx context.Context, notify func(RequestAttempt)) context.Context {
+	return context.WithValue(ctx, retryNotifyKey{}, notify)
+}
+
+func newRetryTransport(base http.RoundTripper, options RequestOptions) *retryTransport {
+	backoff := time.Duration(options.RetryBackoff) * time.Millisecond
+	if backoff <= 0 {
+		backoff = defaultRetryBackoff
+	}
+
+	statuses := options.RetryStatus
+	if len(statuses) == 0 {
+		statuses = defaultRetryStatus
+	}
+
+	return &retryTransport{
+		base:     base,
+		retries:  options.Retries,
+		backoff:  backoff,
+		statuses: statuses,
+	}
+}
+
+func (t *retryTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	ctx := req.Context()
+	replayable := req.Body == nil || req.Body == http.NoBody || req.GetBody != nil
+
+	for attempt := 1; ; attempt++ {
+		resp, err := t.base.RoundTrip(req)
+
+		reason, retry := t.shouldRetry(ctx, resp, err)
+		if !retry || !replayable || attempt > t.retries {
+			return resp, err
+		}
+
+		delay := t.delay(attempt, resp)
+		if resp != nil {
+			_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 64*1024))
+			resp.Body.Close()
+		}
+
+		log.Printf("Retrying %s %s in %v (%d/%d): %s", req.Method, req.URL, delay, attempt, t.retries, reason)
+		if notify, ok := ctx.Value(retryNotifyKey{}).(func(RequestAttempt)); ok {
+			notify(RequestAttempt{
+				Attempt:  attempt + 1,
+				Attempts: t.retries + 1,
+				Delay:    delay.Milliseconds(),
+				Error:    reason,
+			})
+		}
+
+		timer := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return nil, ctx.Err()
+		case <-timer.C:
+		}
+
+		next := req.Clone(ctx)
+		if req.GetBody != nil {
+			body, err := req.GetBody()
+			if err != nil {
+				return nil, err
+			}
+			next.Body = body
+		}
+		req = next
+	}
+}
+
+// shouldRetry reports why the attempt failed and whether that is worth another one
+func (t *retryTransport) shouldRetry(ctx context.Context, resp *http.Response, err error) (string, bool) {
+	if err != nil {
+		// canceled or timed out as a whole, not a transient failure
+		if ctx.Err() != nil {
+			return "", false
+		}
+		return err.Error(), true
+	}
+	if slices.Contains(t.statuses, resp.StatusCode) {
+		return resp.Status, true
+	}
+	return "", false
+}
+
+// delay returns how long to wait before the attempt after attempt, preferring the
+// server's Retry-After over exponential backoff with jitter
+func (t *retryTransport) delay(attempt int, resp *http.Response) time.Duration {
+	if resp != nil {
+		if d, ok := retryAfter(resp.Header.Get("Retry-After")); ok {
+			return min(d, maxRetryAfter)
+		}
+	}
+
+	d := t.backoff << min(attempt-1, 16)
+	d = min(d, maxRetryBackoff)
+
+	return d/2 + rand.N(d/2+1)
+}
+
+// retryAfter parses a Retry-After value given either in seconds or as an HTTP date
+func retryAfter(value string) (time.Duration, bool) {
+	if value == "" {
+		return 0, false
+	}
+	if seconds, err := strconv.Atoi(value); err == nil && seconds >= 0 {
+		return time.Duration(seconds) * time.Second, true
+	}
+	if date, err := http.ParseTime(value); err == nil {
+		return max(time.Until(date), 0), true
+	}
+	return 0, false
+}
@@ -0,0 +1,145 @@
+package bridge
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestRetryAfter(t *testing.T) {
+	if d, ok := retryAfter("120"); !ok || d != 2*time.Minute {
+		t.Errorf("seconds: %v, %v", d, ok)
+	}
+	if d, ok := retryAfter(time.Now().Add(time.Hour).UTC().Format(http.TimeFormat)); !ok || d < 59*time.Minute || d > time.Hour {
+		t.Errorf("date: %v, %v", d, ok)
+	}
+	if d, ok := retryAfter(time.Now().Add(-time.Hour).UTC().Format(http.TimeFormat)); !ok || d != 0 {
+		t.Errorf("past date: %v, %v", d, ok)
+	}
+	for _, value := range []string{"", "-1", "soon", "1.5"} {
+		if _, ok := retryAfter(value); ok {
+			t.Errorf("%q was accepted", value)
+		}
+	}
+}
+
+func TestRetryDelay(t *testing.T) {
+	transport := newRetryTransport(http.DefaultTransport, RequestOptions{RetryBackoff: 100})
+
+	resp := func(retryAfter string) *http.Response {
+		return &http.Response{Header: http.Header{"Retry-After": {retryAfter}}}
+	}
+	if d := transport.delay(1, resp("3")); d != 3*time.Second {
+		t.Errorf("Retry-After 3 = %v", d)
+	}
+	if d := transport.delay(1, resp("86400")); d != maxRetryAfter {
+		t.Errorf("Retry-After is not capped: %v", d)
+	}
+
+	for attempt, want := range map[int]time.Duration{1: 100 * time.Millisecond, 3: 400 * time.Millisecond, 20: maxRetryBackoff} {
+		if d := transport.delay(attempt, nil); d < want/2 || d > want {
+			t.Errorf("attempt %d = %v, want between %v and %v", attempt, d, want/2, want)
+		}
+	}
+}
+
+// flakyServer answers the first failures requests with 503 and the rest with 200
+func flakyServer(t *testing.T, failures int32, retryAfter string) (*atomic.Int32, *httptest.Server) {
+	var requests atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.Copy(io.Discard, r.Body)
+		if requests.Add(1) <= failures {
+			if retryAfter != "" {
+				w.Header().Set("Retry-After", retryAfter)
+			}
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.Write([]byte("ok"))
+	}))
+	t.Cleanup(server.Close)
+	return &requests, server
+}
+
+func TestRetryTransport(t *testing.T) {
+	requests, server := flakyServer(t, 2, "0")
+	client := &http.Client{Transport: newRetryTransport(http.DefaultTransport, RequestOptions{Retries: 2})}
+
+	var attempts []RequestAttempt
+	ctx := withRetryNotify(context.Background(), func(attempt RequestAttempt) {
+		attempts = append(attempts, attempt)
+	})
+
+	req, _ := http.NewRequestWithContext(ctx, http.MethodPost, server.URL, strings.NewReader("body"))
+	start := time.Now()
+	resp, err := client.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK || requests.Load() != 3 {
+		t.Fatalf("status %d after %d requests", resp.StatusCode, requests.Load())
+	}
+	// Retry-After: 0 wins over the default one second backoff
+	if elapsed := time.Since(start); elapsed > 500*time.Millisecond {
+		t.Errorf("Retry-After was not honored, took %v", elapsed)
+	}
+	if len(attempts) != 2 || attempts[0].Attempt != 2 || attempts[1].Attempt != 3 || attempts[0].Attempts != 3 || attempts[0].Error != "503 Service Unavailable" {
+		t.Errorf("notified attempts = %+v", attempts)
+	}
+}
+
+func TestRetryTransportGivesUp(t *testing.T) {
+	requests, server := flakyServer(t, 5, "")
+	client := &http.Client{Transport: newRetryTransport(http.DefaultTransport, RequestOptions{Retries: 1, RetryBackoff: 1})}
+
+	resp, err := client.Get(server.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+
+	if resp.StatusCode != http.StatusServiceUnavailable || requests.Load() != 2 {
+		t.Errorf("status %d after %d requests, want the last 503 after 2", resp.StatusCode, requests.Load())
+	}
+}
+
+func TestRetryTransportSkipsUnreplayableBody(t *testing.T) {
+	requests, server := flakyServer(t, 1, "0")
+	client := &http.Client{Transport: newRetryTransport(http.DefaultTransport, RequestOptions{Retries: 3})}
+
+	// a plain reader has no GetBody, it cannot be sent a second time
+	req, _ := http.NewRequest(http.MethodPost, server.URL, io.NopCloser(strings.NewReader("body")))
+	resp, err := client.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+
+	if resp.StatusCode != http.StatusServiceUnavailable || requests.Load() != 1 {
+		t.Errorf("status %d after %d requests, want a single 503", resp.StatusCode, requests.Load())
+	}
+}
+
+func TestRetryTransportStopsOnCancel(t *testing.T) {
+	_, server := flakyServer(t, 5, "60")
+	client := &http.Client{Transport: newRetryTransport(http.DefaultTransport, RequestOptions{Retries: 3})}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	ctx = withRetryNotify(ctx, func(RequestAttempt) { cancel() })
+
+	req, _ := http.NewRequestWithContext(ctx, http.MethodGet, server.URL, nil)
+	start := time.Now()
+	if _, err := client.Do(req); err == nil {
+		t.Fatal("expected the canceled request to fail")
+	}
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Errorf("waited out Retry-After after cancel, took %v", elapsed)
+	}
+}
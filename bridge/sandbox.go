@@ -0,0 +1,193 @@
+package bridge
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/json"
+	"errors"
+	"log"
+	"slices"
+	"sync"
+
+	"github.com/wailsapp/wails/v2/pkg/runtime"
+)
+
+const (
+	// sandboxAppCaller is the caller id of the app's own frontend code
+	sandboxAppCaller = "app"
+	// sandboxSessionEvent hands the app token to the page that has just loaded
+	sandboxSessionEvent = "onSandboxSession"
+)
+
+var (
+	sandboxMu     sync.RWMutex
+	sandboxRoots  []string
+	sandboxGrants = make(map[string][]string) // caller id -> paths
+	sandboxTokens = make(map[string]string)   // token -> caller id
+)
+
+// initSandbox sets up the roots bridge file operations are confined to, taken from
+// sandboxRoots in user.yaml and defaulting to the data directory, along with any
+// grants configured there
+func initSandbox() {
+	sandboxMu.Lock()
+	defer sandboxMu.Unlock()
+
+	roots := Config.SandboxRoots
+	if len(roots) == 0 {
+		roots = []string{"data"}
+	}
+
+	sandboxRoots = nil
+	for _, root := range roots {
+		sandboxRoots = append(sandboxRoots, resolvePath(root))
+	}
+
+	resetSandboxGrants()
+}
+
+// resetSandboxGrants drops all tokens and runtime grants, leaving the configured grants.
+// The caller must hold sandboxMu.
+func resetSandboxGrants() {
+	sandboxGrants = make(map[string][]string)
+	for id, paths := range Config.SandboxGrants {
+		for _, path := range paths {
+			sandboxGrants[id] = append(sandboxGrants[id], resolvePath(path))
+		}
+	}
+
+	sandboxTokens = make(map[string]string)
+}
+
+// StartSandboxSession is called from OnDomReady, whenever the webview has finished
+// (re)loading the frontend. Tokens belong to the page they were issued to, so those of
+// the previous page are dropped and the new one is sent a fresh app token. The app
+// token is only ever given out on this event, which the app listens for before any
+// plugin runs, never by a binding a plugin could call.
+func StartSandboxSession(ctx context.Context) {
+	runtime.EventsEmit(ctx, sandboxSessionEvent, startSandboxSession())
+}
+
+func startSandboxSession() string {
+	sandboxMu.Lock()
+	defer sandboxMu.Unlock()
+
+	resetSandboxGrants()
+	return newSandboxToken(sandboxAppCaller)
+}
+
+// sandboxPath resolves path like resolvePath and fails unless it lies inside one of the
+// sandbox roots or the paths granted to the caller token belongs to. Symlinks are
+// resolved first, so a link inside a root cannot be used to reach files outside of it.
+func sandboxPath(path string, token string) (string, error) {
+	fullPath := resolvePath(path)
+
+	realPath, err := resolveExistingPath(fullPath)
+	if err != nil {
+		return "", err
+	}
+
+	sandboxMu.RLock()
+	defer sandboxMu.RUnlock()
+
+	allowed := slices.Clone(sandboxRoots)
+	if caller, ok := sandboxTokens[token]; ok {
+		allowed = append(allowed, sandboxGrants[caller]...)
+	}
+
+	for _, root := range allowed {
+		if realRoot, err := resolveExistingPath(root); err == nil && pathWithin(realRoot, realPath) {
+			return fullPath, nil
+		}
+	}
+
+	return "", errors.New("path outside sandbox: " + fullPath)
+}
+
+// newSandboxToken issues a token for caller. The caller must hold sandboxMu.
+func newSandboxToken(caller string) string {
+	token := rand.Text()
+	sandboxTokens[token] = caller
+	return token
+}
+
+// SandboxToken hands out the token file operations of the plugin id carry to use the
+// grants configured for it. Only the app may ask for plugin tokens.
+func (a *App) SandboxToken(appToken string, id string) FlagResult {
+	log.Printf("SandboxToken: %s", id)
+
+	sandboxMu.Lock()
+	defer sandboxMu.Unlock()
+
+	if sandboxTokens[appToken] != sandboxAppCaller {
+		return FlagResult{false, "invalid sandbox token"}
+	}
+	if id == "" || id == sandboxAppCaller {
+		return FlagResult{false, "invalid caller id: " + id}
+	}
+
+	return FlagResult{true, newSandboxToken(id)}
+}
+
+// GrantSandboxPath allows file operations carrying appToken to use path and everything
+// below it. Only the app may grant, and only to itself; plugins get their grants from
+// sandboxGrants in user.yaml. Grants are counted, each one is undone by one revoke.
+func (a *App) GrantSandboxPath(appToken string, path string) FlagResult {
+	log.Printf("GrantSandboxPath: %s", path)
+
+	if path == "" {
+		return FlagResult{false, "path is required"}
+	}
+
+	sandboxMu.Lock()
+	defer sandboxMu.Unlock()
+
+	if sandboxTokens[appToken] != sandboxAppCaller {
+		return FlagResult{false, "invalid sandbox token"}
+	}
+
+	sandboxGrants[sandboxAppCaller] = append(sandboxGrants[sandboxAppCaller], resolvePath(path))
+
+	return FlagResult{true, "Success"}
+}
+
+// RevokeSandboxPath undoes one grant of path to the app
+func (a *App) RevokeSandboxPath(appToken string, path string) FlagResult {
+	log.Printf("RevokeSandboxPath: %s", path)
+
+	sandboxMu.Lock()
+	defer sandboxMu.Unlock()
+
+	if sandboxTokens[appToken] != sandboxAppCaller {
+		return FlagResult{false, "invalid sandbox token"}
+	}
+
+	paths := sandboxGrants[sandboxAppCaller]
+	index := slices.Index(paths, resolvePath(path))
+	if index == -1 {
+		return FlagResult{false, "grant not found"}
+	}
+
+	paths = slices.Delete(paths, index, index+1)
+	if len(paths) == 0 {
+		delete(sandboxGrants, sandboxAppCaller)
+	} else {
+		sandboxGrants[sandboxAppCaller] = paths
+	}
+
+	return FlagResult{true, "Success"}
+}
+
+func (a *App) GetSandbox() FlagResult {
+	log.Printf("GetSandbox")
+
+	sandboxMu.RLock()
+	b, err := json.Marshal(SandboxInfo{Roots: sandboxRoots, Grants: sandboxGrants})
+	sandboxMu.RUnlock()
+
+	if err != nil {
+		return FlagResult{false, err.Error()}
+	}
+
+	return FlagResult{true, string(b)}
+}
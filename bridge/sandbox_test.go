@@ -0,0 +1,174 @@
+package bridge
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+func expectOutsideSandbox(t *testing.T, name string, result FlagResult) {
+	t.Helper()
+	if result.Flag || !strings.Contains(result.Data, "path outside sandbox") {
+		t.Errorf("%s = %+v, want path outside sandbox", name, result)
+	}
+}
+
+func TestSandboxPath(t *testing.T) {
+	dir := useSandbox(t)
+	outside := filepath.ToSlash(t.TempDir())
+
+	if _, err := sandboxPath(dir+"/data/file.txt", ""); err != nil {
+		t.Errorf("path inside the root: %v", err)
+	}
+	if _, err := sandboxPath("data/../file.txt", ""); err != nil {
+		t.Errorf("relative path inside the root: %v", err)
+	}
+	if _, err := sandboxPath(outside+"/file.txt", ""); err == nil {
+		t.Error("path outside the root was allowed")
+	}
+	if _, err := sandboxPath(dir+"/../file.txt", ""); err == nil {
+		t.Error("path escaping the root with .. was allowed")
+	}
+
+	if runtime.GOOS != "windows" {
+		if err := os.Symlink(outside, filepath.Join(dir, "link")); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := sandboxPath(dir+"/link/file.txt", ""); err == nil {
+			t.Error("path escaping the root through a symlink was allowed")
+		}
+	}
+}
+
+func TestSandboxGrantsArePerCaller(t *testing.T) {
+	useSandbox(t)
+	a := &App{}
+
+	outside := filepath.ToSlash(t.TempDir())
+	file := outside + "/file.txt"
+	os.WriteFile(file, []byte("data"), 0644)
+
+	appToken := startSandboxSession()
+	if result := a.SandboxToken("", ""); result.Flag {
+		t.Error("a token was issued without the app token")
+	}
+
+	if result := a.GrantSandboxPath("", outside); result.Flag {
+		t.Error("granted without a token")
+	}
+	if result := a.GrantSandboxPath("guessed", outside); result.Flag {
+		t.Error("granted with an unknown token")
+	}
+	if result := a.GrantSandboxPath(appToken, outside); !result.Flag {
+		t.Fatal(result.Data)
+	}
+
+	read := func(token string) FlagResult {
+		return a.ReadFile(file, IOOptions{Mode: Text, Token: token})
+	}
+	if result := read(appToken); !result.Flag || result.Data != "data" {
+		t.Errorf("app token: %+v", result)
+	}
+	expectOutsideSandbox(t, "without token", read(""))
+
+	result := a.SandboxToken(appToken, "plugin")
+	if !result.Flag {
+		t.Fatal(result.Data)
+	}
+	pluginToken := result.Data
+	expectOutsideSandbox(t, "plugin token", read(pluginToken))
+
+	if result := a.SandboxToken(pluginToken, "other"); result.Flag {
+		t.Error("a plugin token issued another token")
+	}
+	if result := a.SandboxToken(appToken, sandboxAppCaller); result.Flag {
+		t.Error("a second app token was issued")
+	}
+	if result := a.GrantSandboxPath(pluginToken, outside); result.Flag {
+		t.Error("a plugin granted itself a path")
+	}
+
+	if result := a.RevokeSandboxPath(appToken, outside); !result.Flag {
+		t.Fatal(result.Data)
+	}
+	expectOutsideSandbox(t, "revoked", read(appToken))
+
+	// a reloaded page gets a fresh app token, the old ones are void
+	a.GrantSandboxPath(appToken, outside)
+	newToken := startSandboxSession()
+	expectOutsideSandbox(t, "after reload", a.FileExists(file, FileOptions{Token: appToken}))
+	if result := a.SandboxToken(appToken, "plugin"); result.Flag {
+		t.Error("the old app token issued a plugin token")
+	}
+	expectOutsideSandbox(t, "grant of the old page", a.FileExists(file, FileOptions{Token: newToken}))
+}
+
+func TestSandboxSessionIgnoresIndexRequests(t *testing.T) {
+	useSandbox(t)
+	a := &App{}
+
+	outside := filepath.ToSlash(t.TempDir())
+	appToken := startSandboxSession()
+	if result := a.GrantSandboxPath(appToken, outside); !result.Flag {
+		t.Fatal(result.Data)
+	}
+
+	// the page itself may request the index, only a finished load starts a new session
+	handler := RollingRelease(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+
+	if result := a.FileExists(outside, FileOptions{Token: appToken}); !result.Flag {
+		t.Errorf("app token lost after requesting the index: %+v", result)
+	}
+}
+
+func TestSandboxConfiguredGrants(t *testing.T) {
+	useSandbox(t)
+	a := &App{}
+
+	outside := filepath.ToSlash(t.TempDir())
+	os.WriteFile(outside+"/file.txt", []byte("data"), 0644)
+
+	Config.SandboxGrants = map[string][]string{"plugin": {outside}}
+	t.Cleanup(func() { Config.SandboxGrants = nil })
+	appToken := startSandboxSession()
+	pluginToken := a.SandboxToken(appToken, "plugin").Data
+	otherToken := a.SandboxToken(appToken, "other").Data
+
+	if result := a.FileExists(outside+"/file.txt", FileOptions{Token: pluginToken}); !result.Flag || result.Data != "true" {
+		t.Errorf("granted plugin: %+v", result)
+	}
+	expectOutsideSandbox(t, "other plugin", a.FileExists(outside+"/file.txt", FileOptions{Token: otherToken}))
+	expectOutsideSandbox(t, "app", a.FileExists(outside+"/file.txt", FileOptions{Token: appToken}))
+}
+
+func TestSandboxedBindings(t *testing.T) {
+	useSandbox(t)
+	a := &App{}
+
+	outside := filepath.ToSlash(t.TempDir())
+	file := outside + "/file.txt"
+	os.WriteFile(file, []byte("data"), 0644)
+	os.WriteFile(outside+"/sums.txt", []byte("3a6eb0790f39ac87c94f3856b2dd2c5d110e6811602261a9a923d3bb23adc8b7  file.txt\n"), 0644)
+
+	expectOutsideSandbox(t, "ReadDir", a.ReadDir(outside, FileOptions{}))
+	expectOutsideSandbox(t, "ListDir", a.ListDir(outside, ListDirOptions{}))
+	expectOutsideSandbox(t, "FileExists", a.FileExists(file, FileOptions{}))
+	expectOutsideSandbox(t, "FileHash", a.FileHash(file, "sha256", FileOptions{}))
+	expectOutsideSandbox(t, "FileSHA256", a.FileSHA256(file, FileOptions{}))
+	expectOutsideSandbox(t, "VerifyChecksumFile", a.VerifyChecksumFile(outside+"/sums.txt", file, FileOptions{}))
+	expectOutsideSandbox(t, "OpenDir", a.OpenDir(outside, FileOptions{}))
+	expectOutsideSandbox(t, "AbsolutePath", a.AbsolutePath(file, FileOptions{}))
+	expectOutsideSandbox(t, "WatchPath", a.WatchPath(outside, "changed", WatchOptions{}))
+	expectOutsideSandbox(t, "MoveFile", a.MoveFile(file, "data/file.txt", FileOptions{}))
+	expectOutsideSandbox(t, "CopyFile", a.CopyFile(file, "data/file.txt", FileOptions{}))
+	expectOutsideSandbox(t, "RemoveFile", a.RemoveFile(file, RemoveOptions{}))
+
+	if _, err := os.Stat(file); err != nil {
+		t.Errorf("file was touched: %v", err)
+	}
+}
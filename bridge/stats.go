@@ -0,0 +1,227 @@
+package bridge
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/shirou/gopsutil/v3/process"
+	"github.com/wailsapp/wails/v2/pkg/runtime"
+)
+
+var samplerMap sync.Map
+
+// statsProcessMap keeps the process handle of every pid ProcessStats was called for, so
+// each call measures cpu usage since the previous one instead of sampling on the spot.
+// Handles are dropped once their process exits or a sampler of the pid stops.
+var statsProcessMap sync.Map
+
+type statsProcess struct {
+	mu         sync.Mutex
+	proc       *process.Process
+	createTime int64
+	sampled    bool
+}
+
+func (a *App) ProcessStats(pid int32) FlagResult {
+	log.Printf("ProcessStats: %d", pid)
+
+	sp, err := loadStatsProcess(pid)
+	if err != nil {
+		return FlagResult{false, err.Error()}
+	}
+
+	sp.mu.Lock()
+	stats, err := collectProcessStats(sp.proc)
+	if err == nil && !sp.sampled {
+		// there is no previous call to compare with yet
+		stats.CPUPercent = lifetimeCPUPercent(sp.proc)
+	}
+	sp.sampled = true
+	sp.mu.Unlock()
+
+	if err != nil {
+		statsProcessMap.CompareAndDelete(pid, sp)
+		return FlagResult{false, err.Error()}
+	}
+
+	b, err := json.Marshal(stats)
+	if err != nil {
+		return FlagResult{false, err.Error()}
+	}
+
+	return FlagResult{true, string(b)}
+}
+
+// loadStatsProcess returns the cached handle for pid, replacing it if the pid now belongs
+// to a different process
+func loadStatsProcess(pid int32) (*statsProcess, error) {
+	proc, err := process.NewProcess(pid)
+	if err != nil {
+		statsProcessMap.Delete(pid)
+		return nil, err
+	}
+
+	createTime, err := proc.CreateTime()
+	if err != nil {
+		return nil, err
+	}
+
+	if val, ok := statsProcessMap.Load(pid); ok {
+		if sp := val.(*statsProcess); sp.createTime == createTime {
+			return sp, nil
+		}
+	}
+
+	pruneStatsProcesses()
+
+	sp := &statsProcess{proc: proc, createTime: createTime}
+	statsProcessMap.Store(pid, sp)
+
+	return sp, nil
+}
+
+// pruneStatsProcesses drops the handles of processes that are gone, including ones not
+// started by the app that ProcessStats is never asked about again
+func pruneStatsProcesses() {
+	statsProcessMap.Range(func(key, val any) bool {
+		if running, err := val.(*statsProcess).proc.IsRunning(); err == nil && !running {
+			statsProcessMap.CompareAndDelete(key, val)
+		}
+		return true
+	})
+}
+
+func evictStatsProcess(pid int32) {
+	statsProcessMap.Delete(pid)
+}
+
+// lifetimeCPUPercent returns the average cpu usage since the process started
+func lifetimeCPUPercent(proc *process.Process) float64 {
+	times, err := proc.Times()
+	if err != nil {
+		return 0
+	}
+	createTime, err := proc.CreateTime()
+	if err != nil {
+		return 0
+	}
+
+	elapsed := time.Since(time.UnixMilli(createTime)).Seconds()
+	if elapsed <= 0 {
+		return 0
+	}
+
+	return (times.User + times.System) / elapsed * 100
+}
+
+func (a *App) StartProcessSampler(pid int32, event string, interval int) FlagResult {
+	log.Printf("StartProcessSampler: %d %s %d", pid, event, interval)
+
+	if event == "" {
+		return FlagResult{false, "event is required"}
+	}
+
+	proc, err := process.NewProcess(pid)
+	if err != nil {
+		return FlagResult{false, err.Error()}
+	}
+
+	stop := make(chan struct{})
+	if _, exists := samplerMap.LoadOrStore(event, stop); exists {
+		return FlagResult{false, "sampler already exists"}
+	}
+
+	go func() {
+		defer samplerMap.CompareAndDelete(event, stop)
+		defer evictStatsProcess(pid)
+
+		ticker := time.NewTicker(time.Duration(max(interval, 250)) * time.Millisecond)
+		defer ticker.Stop()
+
+		_, _ = proc.Percent(0)
+
+		for {
+			select {
+			case <-stop:
+				return
+			case <-ticker.C:
+				stats, err := collectProcessStats(proc)
+				if err != nil {
+					log.Printf("Process sampler [%s] stopped: %v", event, err)
+					return
+				}
+				runtime.EventsEmit(a.Ctx, event, stats)
+			}
+		}
+	}()
+
+	return FlagResult{true, "Success"}
+}
+
+// StopProcessSamplers stops all samplers. It is called when the frontend is (re)loaded,
+// which drops every subscriber to their events.
+func StopProcessSamplers() {
+	samplerMap.Range(func(key, val any) bool {
+		if samplerMap.CompareAndDelete(key, val) {
+			close(val.(chan struct{}))
+		}
+		return true
+	})
+}
+
+func (a *App) StopProcessSampler(event string) FlagResult {
+	log.Printf("StopProcessSampler: %s", event)
+
+	val, ok := samplerMap.LoadAndDelete(event)
+	if !ok {
+		return FlagResult{false, "sampler not found"}
+	}
+	close(val.(chan struct{}))
+
+	return FlagResult{true, "Success"}
+}
+
+// collectProcessStats gathers what the platform supports, individual metrics
+// that cannot be read are left at zero
+func collectProcessStats(proc *process.Process) (ProcessStatsResult, error) {
+	stats := ProcessStatsResult{Pid: proc.Pid}
+
+	running, err := proc.IsRunning()
+	if err != nil {
+		return stats, err
+	}
+	if !running {
+		return stats, errors.New("process is not running")
+	}
+
+	if percent, err := proc.Percent(0); err == nil {
+		stats.CPUPercent = percent
+	}
+	if memInfo, err := proc.MemoryInfo(); err == nil && memInfo != nil {
+		stats.MemoryRSS = memInfo.RSS
+		stats.MemoryVMS = memInfo.VMS
+	}
+	if threads, err := proc.NumThreads(); err == nil {
+		stats.NumThreads = threads
+	}
+	if fds, err := proc.NumFDs(); err == nil {
+		stats.NumFDs = fds
+	}
+	if conns, err := proc.Connections(); err == nil {
+		stats.NumConnections = len(conns)
+	}
+	if createTime, err := proc.CreateTime(); err == nil {
+		stats.Uptime = int64(time.Since(time.UnixMilli(createTime)).Seconds())
+	}
+	if io, err := proc.IOCounters(); err == nil && io != nil {
+		stats.ReadCount = io.ReadCount
+		stats.WriteCount = io.WriteCount
+		stats.ReadBytes = io.ReadBytes
+		stats.WriteBytes = io.WriteBytes
+	}
+
+	return stats, nil
+}
@@ -0,0 +1,140 @@
+package bridge
+
+import (
+	"encoding/json"
+	"os"
+	"os/exec"
+	"runtime"
+	"testing"
+	"time"
+)
+
+func TestProcessStats(t *testing.T) {
+	a := &App{}
+	pid := int32(os.Getpid())
+
+	for range 2 {
+		start := time.Now()
+		result := a.ProcessStats(pid)
+		if !result.Flag {
+			t.Fatalf("ProcessStats: %s", result.Data)
+		}
+		if elapsed := time.Since(start); elapsed >= 200*time.Millisecond {
+			t.Errorf("ProcessStats took %v", elapsed)
+		}
+
+		var stats ProcessStatsResult
+		if err := json.Unmarshal([]byte(result.Data), &stats); err != nil {
+			t.Fatal(err)
+		}
+		if stats.Pid != pid || stats.MemoryRSS == 0 {
+			t.Errorf("unexpected stats: %+v", stats)
+		}
+	}
+
+	if _, ok := statsProcessMap.Load(pid); !ok {
+		t.Error("process handle was not kept for the next call")
+	}
+}
+
+func TestStopProcessSamplers(t *testing.T) {
+	a := &App{}
+	pid := int32(os.Getpid())
+
+	for _, event := range []string{"sampler-a", "sampler-b"} {
+		if result := a.StartProcessSampler(pid, event, 60*1000); !result.Flag {
+			t.Fatalf("StartProcessSampler: %s", result.Data)
+		}
+	}
+
+	StopProcessSamplers()
+
+	for _, event := range []string{"sampler-a", "sampler-b"} {
+		if _, ok := samplerMap.Load(event); ok {
+			t.Errorf("sampler %s still registered", event)
+		}
+	}
+
+	if result := a.StartProcessSampler(pid, "sampler-a", 60*1000); !result.Flag {
+		t.Errorf("a stopped sampler's event cannot be reused: %s", result.Data)
+	}
+	a.StopProcessSampler("sampler-a")
+}
+
+func waitStatsEvicted(t *testing.T, pid int32) {
+	t.Helper()
+
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		if _, ok := statsProcessMap.Load(pid); !ok {
+			return
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("handle of %d is still kept", pid)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestProcessStatsEvictedOnExit(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("needs sh")
+	}
+
+	Env.BasePath = t.TempDir()
+	a := &App{}
+
+	startTestProcess(t, a, "stats-exit", "exec sleep 10", ExecOptions{})
+	record, _ := getProcessRecord(a, "stats-exit")
+	pid := int32(record.Pid)
+
+	if result := a.ProcessStats(pid); !result.Flag {
+		t.Fatal(result.Data)
+	}
+	if _, ok := statsProcessMap.Load(pid); !ok {
+		t.Fatal("process handle was not kept")
+	}
+
+	a.KillProcess(record.Pid, 1)
+	waitProcessExit(t, a, "stats-exit")
+	waitStatsEvicted(t, pid)
+}
+
+func TestProcessStatsEvictedWhenSamplerStops(t *testing.T) {
+	a := &App{}
+	pid := int32(os.Getpid())
+
+	a.ProcessStats(pid)
+	if result := a.StartProcessSampler(pid, "sampler-evict", 60*1000); !result.Flag {
+		t.Fatal(result.Data)
+	}
+	a.StopProcessSampler("sampler-evict")
+	waitStatsEvicted(t, pid)
+}
+
+func TestProcessStatsPrunesGoneProcesses(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("needs sh")
+	}
+
+	// a process the app did not start, which ProcessStats is not asked about again
+	cmd := exec.Command("sh", "-c", "exec sleep 10")
+	if err := cmd.Start(); err != nil {
+		t.Fatal(err)
+	}
+	pid := int32(cmd.Process.Pid)
+
+	a := &App{}
+	if result := a.ProcessStats(pid); !result.Flag {
+		t.Fatal(result.Data)
+	}
+	cmd.Process.Kill()
+	cmd.Wait()
+
+	// storing another handle sweeps the gone ones
+	statsProcessMap.Delete(int32(os.Getpid()))
+	a.ProcessStats(int32(os.Getpid()))
+	if _, ok := statsProcessMap.Load(pid); ok {
+		t.Error("handle of a gone process was kept")
+	}
+}
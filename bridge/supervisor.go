@@ -0,0 +1,264 @@
+package bridge
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/wailsapp/wails/v2/pkg/runtime"
+)
+
+const (
+	SupervisorStarting   = "starting"
+	SupervisorRunning    = "running"
+	SupervisorCrashed    = "crashed"
+	SupervisorBackingOff = "backing-off"
+	SupervisorStopped    = "stopped"
+)
+
+var supervisorMap sync.Map
+
+type supervisor struct {
+	mu         sync.Mutex
+	app        *App
+	path       string
+	args       []string
+	outEvent   string
+	stateEvent string
+	options    SupervisorOptions
+	info       SupervisorInfo
+	process    *backgroundProcess
+	stopping   bool
+	stop       chan struct{}
+	done       chan struct{}
+}
+
+func (a *App) StartSupervised(id string, path string, args []string, outEvent string, stateEvent string, options SupervisorOptions) FlagResult {
+	log.Printf("StartSupervised: %s %s %s %s %s %v", id, path, args, outEvent, stateEvent, options)
+
+	if options.Exec.Id == "" {
+		options.Exec.Id = id
+	}
+	if options.RestartWindow <= 0 {
+		options.RestartWindow = 300
+	}
+	if options.BackoffInitial <= 0 {
+		options.BackoffInitial = 1000
+	}
+	if options.BackoffMax < options.BackoffInitial {
+		options.BackoffMax = max(options.BackoffInitial, 60*1000)
+	}
+
+	s := &supervisor{
+		app:        a,
+		path:       path,
+		args:       args,
+		outEvent:   outEvent,
+		stateEvent: stateEvent,
+		options:    options,
+		info:       SupervisorInfo{Id: id},
+		stop:       make(chan struct{}),
+		done:       make(chan struct{}),
+	}
+
+	for {
+		val, exists := supervisorMap.LoadOrStore(id, s)
+		if !exists {
+			break
+		}
+		// one that gave up at its restart limit keeps reporting its status until replaced
+		if !val.(*supervisor).ended() {
+			return FlagResult{false, "supervisor already exists"}
+		}
+		if supervisorMap.CompareAndSwap(id, val, s) {
+			break
+		}
+	}
+
+	go s.run()
+
+	return FlagResult{true, "Success"}
+}
+
+func (a *App) StopSupervised(id string, timeout int) FlagResult {
+	log.Printf("StopSupervised: %s %d", id, timeout)
+
+	val, ok := supervisorMap.Load(id)
+	if !ok {
+		return FlagResult{false, "supervisor not found"}
+	}
+	s := val.(*supervisor)
+
+	// the entry is kept when stopping fails so the stop can be retried
+	if err := s.shutdown(timeout); err != nil {
+		return FlagResult{false, err.Error()}
+	}
+
+	supervisorMap.Delete(id)
+
+	return FlagResult{true, "Success"}
+}
+
+func (a *App) SupervisorStatus(id string) FlagResult {
+	log.Printf("SupervisorStatus: %s", id)
+
+	val, ok := supervisorMap.Load(id)
+	if !ok {
+		return FlagResult{false, "supervisor not found"}
+	}
+	s := val.(*supervisor)
+
+	s.mu.Lock()
+	info := s.info
+	s.mu.Unlock()
+
+	b, err := json.Marshal(info)
+	if err != nil {
+		return FlagResult{false, err.Error()}
+	}
+
+	return FlagResult{true, string(b)}
+}
+
+func (s *supervisor) run() {
+	defer close(s.done)
+
+	backoff := time.Duration(s.options.BackoffInitial) * time.Millisecond
+	backoffMax := time.Duration(s.options.BackoffMax) * time.Millisecond
+	window := time.Duration(s.options.RestartWindow) * time.Second
+	var crashes []time.Time
+
+	for {
+		s.setState(SupervisorStarting, 0, nil)
+
+		proc, err := startBackgroundProcess(s.app, s.path, s.args, s.outEvent, s.options.Exec)
+		if err == nil {
+			s.mu.Lock()
+			s.process = proc
+			s.info.Pid = proc.cmd.Process.Pid
+			stopping := s.stopping
+			s.mu.Unlock()
+
+			// StopSupervised may have run while the process was starting
+			if stopping {
+				_ = SendExitSignal(proc.cmd.Process)
+			}
+
+			s.setState(SupervisorRunning, 0, nil)
+			startedAt := time.Now()
+			err = proc.wait()
+
+			s.mu.Lock()
+			s.process = nil
+			s.info.Pid = 0
+			stopping = s.stopping
+			s.mu.Unlock()
+
+			if stopping {
+				s.setState(SupervisorStopped, 0, nil)
+				return
+			}
+
+			// A process that stayed up longer than the longest backoff is considered healthy again
+			if time.Since(startedAt) >= backoffMax {
+				backoff = time.Duration(s.options.BackoffInitial) * time.Millisecond
+			}
+
+			if err == nil {
+				err = fmt.Errorf("process exited unexpectedly")
+			}
+		}
+
+		s.setState(SupervisorCrashed, 0, err)
+
+		now := time.Now()
+		crashes = append(crashes, now)
+		for len(crashes) > 0 && now.Sub(crashes[0]) > window {
+			crashes = crashes[1:]
+		}
+
+		if s.options.MaxRestarts > 0 && len(crashes) > s.options.MaxRestarts {
+			s.setState(SupervisorStopped, 0, fmt.Errorf("restart limit reached: %d restarts within %d seconds", s.options.MaxRestarts, s.options.RestartWindow))
+			return
+		}
+
+		s.setState(SupervisorBackingOff, backoff, err)
+
+		select {
+		case <-s.stop:
+			s.setState(SupervisorStopped, 0, nil)
+			return
+		case <-time.After(backoff):
+		}
+
+		s.mu.Lock()
+		s.info.Restarts++
+		s.mu.Unlock()
+
+		backoff = min(backoff*2, backoffMax)
+	}
+}
+
+func (s *supervisor) setState(state string, delay time.Duration, err error) {
+	s.mu.Lock()
+	s.info.State = state
+	s.info.Delay = delay.Milliseconds()
+	s.info.Error = ""
+	if err != nil {
+		s.info.Error = err.Error()
+	}
+	s.info.Since = time.Now().UnixMilli()
+	info := s.info
+	s.mu.Unlock()
+
+	log.Printf("Supervisor [%s]: %s %s", info.Id, info.State, info.Error)
+
+	if s.stateEvent != "" {
+		runtime.EventsEmit(s.app.Ctx, s.stateEvent, info)
+	}
+}
+
+// ended reports whether the run loop has exited
+func (s *supervisor) ended() bool {
+	select {
+	case <-s.done:
+		return true
+	default:
+		return false
+	}
+}
+
+// requestStop prevents further restarts and returns the currently running process, if any
+func (s *supervisor) requestStop() *backgroundProcess {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if !s.stopping {
+		s.stopping = true
+		close(s.stop)
+	}
+
+	return s.process
+}
+
+// shutdown stops the supervisor and returns once its run loop has exited
+func (s *supervisor) shutdown(timeout int) error {
+	if proc := s.requestStop(); proc != nil {
+		if err := SendExitSignal(proc.cmd.Process); err != nil {
+			log.Printf("SendExitSignal Err: %s", err.Error())
+		}
+		if err := waitForProcessExitWithTimeout(proc.cmd.Process, timeout); err != nil {
+			return err
+		}
+	}
+
+	// a process that was still starting is signaled by run itself
+	select {
+	case <-s.done:
+		return nil
+	case <-time.After(time.Duration(timeout) * time.Second):
+		return fmt.Errorf("timed out after %d seconds waiting for supervisor %s to stop", timeout, s.info.Id)
+	}
+}
@@ -0,0 +1,118 @@
+package bridge
+
+import (
+	"encoding/json"
+	"runtime"
+	"testing"
+	"time"
+)
+
+func supervisorState(t *testing.T, a *App, id string) SupervisorInfo {
+	t.Helper()
+
+	result := a.SupervisorStatus(id)
+	if !result.Flag {
+		t.Fatalf("SupervisorStatus: %s", result.Data)
+	}
+
+	var info SupervisorInfo
+	if err := json.Unmarshal([]byte(result.Data), &info); err != nil {
+		t.Fatal(err)
+	}
+	return info
+}
+
+func waitSupervisorState(t *testing.T, a *App, id string, state string) SupervisorInfo {
+	t.Helper()
+
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		info := supervisorState(t, a, id)
+		if info.State == state {
+			return info
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("supervisor %s is %s, want %s", id, info.State, state)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestStopSupervisedWaitsForExit(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("needs sh")
+	}
+
+	a := &App{}
+
+	if result := a.StartSupervised("sleeper", "sleep", []string{"30"}, "", "", SupervisorOptions{}); !result.Flag {
+		t.Fatalf("StartSupervised: %s", result.Data)
+	}
+	waitSupervisorState(t, a, "sleeper", SupervisorRunning)
+
+	val, _ := supervisorMap.Load("sleeper")
+	s := val.(*supervisor)
+
+	if result := a.StopSupervised("sleeper", 5); !result.Flag {
+		t.Fatalf("StopSupervised: %s", result.Data)
+	}
+
+	select {
+	case <-s.done:
+	default:
+		t.Fatal("StopSupervised returned before the supervisor exited")
+	}
+
+	if _, ok := supervisorMap.Load("sleeper"); ok {
+		t.Fatal("supervisor entry kept after a successful stop")
+	}
+}
+
+func TestSupervisorRestartLimit(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("needs sh")
+	}
+
+	a := &App{}
+
+	options := SupervisorOptions{MaxRestarts: 2, BackoffInitial: 10, BackoffMax: 20}
+	if result := a.StartSupervised("crasher", "sh", []string{"-c", "exit 3"}, "", "", options); !result.Flag {
+		t.Fatalf("StartSupervised: %s", result.Data)
+	}
+	defer a.StopSupervised("crasher", 5)
+
+	info := waitSupervisorState(t, a, "crasher", SupervisorStopped)
+	if info.Restarts != 2 {
+		t.Errorf("restarts = %d, want 2", info.Restarts)
+	}
+	if info.Error == "" {
+		t.Error("stopping at the restart limit should report an error")
+	}
+}
+
+func TestStartSupervisedReplacesEnded(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("needs sh")
+	}
+
+	a := &App{}
+
+	options := SupervisorOptions{MaxRestarts: 1, BackoffInitial: 10, BackoffMax: 20}
+	if result := a.StartSupervised("replaced", "sh", []string{"-c", "exit 3"}, "", "", options); !result.Flag {
+		t.Fatalf("StartSupervised: %s", result.Data)
+	}
+	defer a.StopSupervised("replaced", 5)
+
+	waitSupervisorState(t, a, "replaced", SupervisorStopped)
+
+	if result := a.StartSupervised("replaced", "sh", []string{"-c", "exec sleep 30"}, "", "", options); !result.Flag {
+		t.Fatalf("restarting after the restart limit: %s", result.Data)
+	}
+	if info := waitSupervisorState(t, a, "replaced", SupervisorRunning); info.Restarts != 0 || info.Error != "" {
+		t.Errorf("replacement inherited the old state: %+v", info)
+	}
+
+	if result := a.StartSupervised("replaced", "sh", []string{"-c", "exec sleep 30"}, "", "", options); result.Flag || result.Data != "supervisor already exists" {
+		t.Errorf("replaced a running supervisor: %+v", result)
+	}
+}
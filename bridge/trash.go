@@ -0,0 +1,351 @@
+package bridge
+
+import (
+	"cmp"
+	"encoding/json"
+	"errors"
+	"io"
+	"io/fs"
+	"log"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"time"
+)
+
+const trashManifest = "manifest.json"
+
+func trashDir() string {
+	return resolvePath("data/.trash")
+}
+
+func (a *App) ListTrash() FlagResult {
+	log.Printf("ListTrash")
+
+	entries, err := readTrash()
+	if err != nil {
+		return FlagResult{false, err.Error()}
+	}
+
+	b, err := json.Marshal(entries)
+	if err != nil {
+		return FlagResult{false, err.Error()}
+	}
+
+	return FlagResult{true, string(b)}
+}
+
+func (a *App) RestoreFromTrash(id string, options FileOptions) FlagResult {
+	log.Printf("RestoreFromTrash: %s", id)
+
+	dir, err := trashEntryDir(id)
+	if err != nil {
+		return FlagResult{false, err.Error()}
+	}
+
+	entry, err := readTrashManifest(dir)
+	if err != nil {
+		return FlagResult{false, err.Error()}
+	}
+
+	target, err := sandboxPath(entry.OriginalPath, options.Token)
+	if err != nil {
+		return FlagResult{false, err.Error()}
+	}
+
+	if _, err := os.Lstat(target); err == nil {
+		return FlagResult{false, "target already exists: " + target}
+	}
+
+	if err := os.MkdirAll(filepath.Dir(target), os.ModePerm); err != nil {
+		return FlagResult{false, err.Error()}
+	}
+
+	if err := movePath(filepath.Join(dir, "files", entry.Name), target); err != nil {
+		return FlagResult{false, err.Error()}
+	}
+
+	if err := os.RemoveAll(dir); err != nil {
+		return FlagResult{false, err.Error()}
+	}
+
+	return FlagResult{true, "Success"}
+}
+
+// EmptyTrash permanently deletes one trash entry, or all of them when id is empty
+func (a *App) EmptyTrash(id string) FlagResult {
+	log.Printf("EmptyTrash: %s", id)
+
+	dir := trashDir()
+	if id != "" {
+		var err error
+		if dir, err = trashEntryDir(id); err != nil {
+			return FlagResult{false, err.Error()}
+		}
+	}
+
+	if err := os.RemoveAll(dir); err != nil {
+		return FlagResult{false, err.Error()}
+	}
+
+	return FlagResult{true, "Success"}
+}
+
+// moveToTrash moves fullPath into data/.trash/<timestamp>/files and records where it
+// came from in the manifest next to it. A missing path is not an error, like os.RemoveAll.
+func moveToTrash(fullPath string) error {
+	stat, err := os.Lstat(fullPath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return err
+	}
+
+	if err := os.MkdirAll(trashDir(), os.ModePerm); err != nil {
+		return err
+	}
+
+	if err := checkTrashOverlap(fullPath); err != nil {
+		return err
+	}
+
+	now := time.Now()
+	var dir string
+	for i := 0; ; i++ {
+		id := now.Format("20060102-150405.000000000")
+		if i > 0 {
+			id += "-" + strconv.Itoa(i)
+		}
+		dir = filepath.Join(trashDir(), id)
+		err := os.Mkdir(dir, os.ModePerm)
+		if err == nil {
+			break
+		}
+		if !os.IsExist(err) {
+			return err
+		}
+	}
+
+	entry := TrashEntry{
+		Id:           filepath.Base(dir),
+		Name:         filepath.Base(fullPath),
+		OriginalPath: relativeToBasePath(fullPath),
+		IsDir:        stat.IsDir(),
+		Size:         pathSize(fullPath),
+		DeletedAt:    now.UnixMilli(),
+	}
+
+	b, err := json.MarshalIndent(entry, "", "  ")
+	if err == nil {
+		err = os.WriteFile(filepath.Join(dir, trashManifest), b, 0644)
+	}
+	if err == nil {
+		err = os.Mkdir(filepath.Join(dir, "files"), os.ModePerm)
+	}
+	if err == nil {
+		err = movePath(fullPath, filepath.Join(dir, "files", entry.Name))
+	}
+	if err != nil {
+		os.RemoveAll(dir)
+		return err
+	}
+
+	purgeTrash()
+
+	return nil
+}
+
+// purgeTrash removes entries older than trashRetentionDays from user.yaml (default 30,
+// negative keeps everything)
+func purgeTrash() {
+	days := Config.TrashRetentionDays
+	if days == 0 {
+		days = 30
+	}
+	if days < 0 {
+		return
+	}
+
+	entries, err := readTrash()
+	if err != nil {
+		log.Printf("Failed to read trash: %v", err)
+		return
+	}
+
+	cutoff := time.Now().AddDate(0, 0, -days).UnixMilli()
+	for _, entry := range entries {
+		if entry.DeletedAt < cutoff {
+			if err := os.RemoveAll(filepath.Join(trashDir(), entry.Id)); err != nil {
+				log.Printf("Failed to purge trash entry %s: %v", entry.Id, err)
+			}
+		}
+	}
+}
+
+// readTrash lists trash entries newest first. Entries without a readable manifest are
+// reported by their directory name and modification time so they can still be purged.
+func readTrash() ([]TrashEntry, error) {
+	dirs, err := os.ReadDir(trashDir())
+	if err != nil {
+		if os.IsNotExist(err) {
+			return []TrashEntry{}, nil
+		}
+		return nil, err
+	}
+
+	entries := []TrashEntry{}
+	for _, d := range dirs {
+		if !d.IsDir() {
+			continue
+		}
+		entry, err := readTrashManifest(filepath.Join(trashDir(), d.Name()))
+		if err != nil {
+			info, err := d.Info()
+			if err != nil {
+				continue
+			}
+			entry = TrashEntry{Name: d.Name(), DeletedAt: info.ModTime().UnixMilli()}
+		}
+		entry.Id = d.Name()
+		entries = append(entries, entry)
+	}
+
+	slices.SortFunc(entries, func(a, b TrashEntry) int {
+		return cmp.Compare(b.DeletedAt, a.DeletedAt)
+	})
+
+	return entries, nil
+}
+
+func readTrashManifest(dir string) (TrashEntry, error) {
+	var entry TrashEntry
+
+	b, err := os.ReadFile(filepath.Join(dir, trashManifest))
+	if err != nil {
+		return entry, err
+	}
+
+	err = json.Unmarshal(b, &entry)
+	return entry, err
+}
+
+// checkTrashOverlap rejects trashing the trash itself, anything inside it, or a directory
+// containing it, which would move the trash into itself
+func checkTrashOverlap(fullPath string) error {
+	trash, err := filepath.EvalSymlinks(trashDir())
+	if err != nil {
+		return err
+	}
+
+	// the path itself is moved, not what a symlink points to, so only its parent is resolved
+	parent, err := filepath.EvalSymlinks(filepath.Dir(fullPath))
+	if err != nil {
+		return err
+	}
+	path := filepath.Join(parent, filepath.Base(fullPath))
+
+	if pathWithin(trash, path) || pathWithin(path, trash) {
+		return errors.New("cannot move to trash, path overlaps the trash directory: " + fullPath)
+	}
+
+	return nil
+}
+
+func trashEntryDir(id string) (string, error) {
+	if id == "" || id == "." || id == ".." || filepath.Base(id) != id {
+		return "", errors.New("invalid trash id: " + id)
+	}
+
+	dir := filepath.Join(trashDir(), id)
+	if _, err := os.Stat(dir); err != nil {
+		return "", err
+	}
+
+	return dir, nil
+}
+
+// renamePath is os.Rename, replaced in tests to move across volumes
+var renamePath = os.Rename
+
+// movePath renames src to dst, falling back to copy and delete only when they are on
+// different volumes. Any other rename failure is returned as is, copying would not fix it.
+func movePath(src string, dst string) error {
+	renameErr := renamePath(src, dst)
+	if renameErr == nil || !isCrossDeviceError(renameErr) {
+		return renameErr
+	}
+
+	if err := copyPath(src, dst); err != nil {
+		os.RemoveAll(dst)
+		return err
+	}
+
+	return os.RemoveAll(src)
+}
+
+func copyPath(src string, dst string) error {
+	return filepath.WalkDir(src, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+
+		rel, err := filepath.Rel(src, path)
+		if err != nil {
+			return err
+		}
+		target := filepath.Join(dst, rel)
+
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+
+		switch {
+		case d.IsDir():
+			return os.MkdirAll(target, info.Mode().Perm())
+		case info.Mode()&os.ModeSymlink != 0:
+			link, err := os.Readlink(path)
+			if err != nil {
+				return err
+			}
+			return os.Symlink(link, target)
+		default:
+			return copyRegularFile(path, target, info.Mode().Perm())
+		}
+	})
+}
+
+func copyRegularFile(src string, dst string, perm fs.FileMode) error {
+	srcFile, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer srcFile.Close()
+
+	dstFile, err := os.OpenFile(dst, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, perm)
+	if err != nil {
+		return err
+	}
+
+	if _, err := io.Copy(dstFile, srcFile); err != nil {
+		dstFile.Close()
+		return err
+	}
+
+	return dstFile.Close()
+}
+
+func pathSize(path string) int64 {
+	var size int64
+	filepath.WalkDir(path, func(_ string, d fs.DirEntry, err error) error {
+		if err == nil && !d.IsDir() {
+			if info, err := d.Info(); err == nil {
+				size += info.Size()
+			}
+		}
+		return nil
+	})
+	return size
+}
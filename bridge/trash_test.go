@@ -0,0 +1,177 @@
+package bridge
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"runtime"
+	"syscall"
+	"testing"
+)
+
+func listTrash(t *testing.T, a *App) []TrashEntry {
+	t.Helper()
+
+	result := a.ListTrash()
+	if !result.Flag {
+		t.Fatal(result.Data)
+	}
+
+	var entries []TrashEntry
+	if err := json.Unmarshal([]byte(result.Data), &entries); err != nil {
+		t.Fatal(err)
+	}
+	return entries
+}
+
+func TestTrashAndRestore(t *testing.T) {
+	dir := useSandbox(t)
+	a := &App{}
+
+	path := filepath.Join(dir, "data", "rules", "file.txt")
+	os.MkdirAll(filepath.Dir(path), os.ModePerm)
+	os.WriteFile(path, []byte("data"), 0644)
+
+	if result := a.RemoveFile("data/rules", RemoveOptions{Trash: true}); !result.Flag {
+		t.Fatal(result.Data)
+	}
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Fatalf("file still exists: %v", err)
+	}
+
+	entries := listTrash(t, a)
+	if len(entries) != 1 || entries[0].OriginalPath != "data/rules" || !entries[0].IsDir || entries[0].Size != 4 {
+		t.Fatalf("entries = %+v", entries)
+	}
+
+	// restoring never overwrites what has been created in the meantime
+	os.MkdirAll(filepath.Dir(path), os.ModePerm)
+	if result := a.RestoreFromTrash(entries[0].Id, FileOptions{}); result.Flag {
+		t.Fatal("restored over an existing path")
+	}
+	os.RemoveAll(filepath.Dir(path))
+
+	if result := a.RestoreFromTrash(entries[0].Id, FileOptions{}); !result.Flag {
+		t.Fatal(result.Data)
+	}
+	if b, err := os.ReadFile(path); err != nil || string(b) != "data" {
+		t.Fatalf("restored file = %q, %v", b, err)
+	}
+	if entries := listTrash(t, a); len(entries) != 0 {
+		t.Errorf("trash not empty after restore: %+v", entries)
+	}
+}
+
+func TestTrashRejectsOverlap(t *testing.T) {
+	dir := useSandbox(t)
+	a := &App{}
+
+	os.MkdirAll(filepath.Join(dir, "data"), os.ModePerm)
+	os.WriteFile(filepath.Join(dir, "data", "file.txt"), []byte("data"), 0644)
+	if result := a.RemoveFile("data/file.txt", RemoveOptions{Trash: true}); !result.Flag {
+		t.Fatal(result.Data)
+	}
+	id := listTrash(t, a)[0].Id
+
+	for _, path := range []string{"data/.trash", "data/.trash/" + id, "data/.trash/" + id + "/files", "data", "."} {
+		if result := a.RemoveFile(path, RemoveOptions{Trash: true}); result.Flag {
+			t.Errorf("%s was moved to the trash", path)
+		}
+	}
+
+	entries := listTrash(t, a)
+	if len(entries) != 1 || entries[0].Id != id {
+		t.Fatalf("trash changed: %+v", entries)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "data", ".trash", id, "files", "file.txt")); err != nil {
+		t.Errorf("trashed file is gone: %v", err)
+	}
+}
+
+func TestMovePathOnlyCopiesAcrossDevices(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("rename semantics differ")
+	}
+
+	dir := t.TempDir()
+	src := filepath.Join(dir, "src")
+	dst := filepath.Join(dir, "dst")
+	os.MkdirAll(src, os.ModePerm)
+	os.WriteFile(filepath.Join(src, "a.txt"), []byte("a"), 0644)
+	os.MkdirAll(dst, os.ModePerm)
+	os.WriteFile(filepath.Join(dst, "b.txt"), []byte("b"), 0644)
+
+	// renaming onto a non-empty directory fails; copying would merge the two and drop src
+	if err := movePath(src, dst); err == nil {
+		t.Fatal("expected the rename error")
+	}
+	if _, err := os.Stat(filepath.Join(src, "a.txt")); err != nil {
+		t.Errorf("source was removed: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(dst, "a.txt")); !os.IsNotExist(err) {
+		t.Errorf("source was copied into the target: %v", err)
+	}
+}
+
+func TestMovePathReportsCopyError(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("rename semantics differ")
+	}
+
+	renamePath = func(src, dst string) error {
+		return &os.LinkError{Op: "rename", Old: src, New: dst, Err: syscall.EXDEV}
+	}
+	t.Cleanup(func() { renamePath = os.Rename })
+
+	dir := t.TempDir()
+	src := filepath.Join(dir, "src")
+	os.MkdirAll(src, os.ModePerm)
+	os.WriteFile(filepath.Join(src, "a.txt"), []byte("a"), 0644)
+	// the copy cannot create its target below a file
+	os.WriteFile(filepath.Join(dir, "file"), []byte("f"), 0644)
+
+	err := movePath(src, filepath.Join(dir, "file", "dst"))
+	if err == nil || errors.Is(err, syscall.EXDEV) || !errors.Is(err, syscall.ENOTDIR) {
+		t.Errorf("err = %v, want the copy error", err)
+	}
+	if _, err := os.Stat(filepath.Join(src, "a.txt")); err != nil {
+		t.Errorf("source was removed: %v", err)
+	}
+
+	// across devices a move still copies and removes the source
+	dst := filepath.Join(dir, "dst")
+	if err := movePath(src, dst); err != nil {
+		t.Fatal(err)
+	}
+	if b, err := os.ReadFile(filepath.Join(dst, "a.txt")); err != nil || string(b) != "a" {
+		t.Errorf("copied file = %q, %v", b, err)
+	}
+	if _, err := os.Stat(src); !os.IsNotExist(err) {
+		t.Errorf("source was kept: %v", err)
+	}
+}
+
+func TestRestoreFromTrashWithToken(t *testing.T) {
+	useSandbox(t)
+	a := &App{}
+
+	outside := filepath.ToSlash(t.TempDir())
+	file := outside + "/file.txt"
+	os.WriteFile(file, []byte("data"), 0644)
+
+	appToken := startSandboxSession()
+	a.GrantSandboxPath(appToken, outside)
+	if result := a.RemoveFile(file, RemoveOptions{Trash: true, Token: appToken}); !result.Flag {
+		t.Fatal(result.Data)
+	}
+	id := listTrash(t, a)[0].Id
+
+	expectOutsideSandbox(t, "RestoreFromTrash", a.RestoreFromTrash(id, FileOptions{}))
+	if result := a.RestoreFromTrash(id, FileOptions{Token: appToken}); !result.Flag {
+		t.Fatal(result.Data)
+	}
+	if b, err := os.ReadFile(file); err != nil || string(b) != "data" {
+		t.Errorf("restored file = %q, %v", b, err)
+	}
+}
@@ -3,6 +3,7 @@ package bridge
 import (
 	"context"
 	"net/http"
+	"sync"
 
 	"github.com/wailsapp/wails/v2/pkg/menu"
 )
@@ -27,22 +28,114 @@ type EnvResult struct {
 }
 
 type RequestOptions struct {
-	Proxy     string
-	Insecure  bool
-	Redirect  bool
-	Timeout   int
-	CancelId  string
-	FileField string
-	Sha256    string
-	Stream    string
+	Proxy        string
+	Insecure     bool
+	Redirect     bool
+	Timeout      int
+	CancelId     string
+	FileField    string
+	Sha256       string
+	Checksum     string // "<algorithm>:<hex>", md5 / sha1 / sha256 / sha512 / blake3
+	Stream       string
+	Segments     int    // concurrent range requests used by Download, 0 or 1 downloads in one stream
+	Cache        bool   // revalidate GET responses kept in data/.cache/http, 304 means not modified
+	Retries      int    // attempts after the first failed one, all within Timeout
+	RetryBackoff int    // milliseconds before the first retry, doubled for each further one
+	RetryStatus  []int  // statuses worth retrying, 408 / 429 / 500 / 502 / 503 / 504 by default
+	RetryEvent   string // Download / Upload: event emitted with the RequestAttempt before every retry, the progress event only carries progress
+	Token        string // sandbox token, Download and Upload may also use the paths granted to its caller
 }
 
 type ExecOptions struct {
-	PidFile           string
-	LogFile           string
-	StopOutputKeyword string
-	WorkingDirectory  string
-	Env               map[string]string
+	Id                  string
+	KeepAlive           bool // leave the process running on ExitApp
+	PidFile             string
+	LogFile             string
+	StopOutputKeyword   string
+	WorkingDirectory    string
+	Env                 map[string]string
+	Timeout             int // seconds, Exec only
+	CancelId            string
+	Stdin               bool   // keep stdin open for WriteProcessStdin, ExecBackground only
+	LogMaxSize          int64  // rotate LogFile once it exceeds this many bytes, 0 = unlimited
+	LogMaxBackups       int    // rotated files to keep, 0 = truncate on rotation
+	LogCompress         bool   // gzip rotated files
+	LogRotateOnStart    bool   // rotate an existing LogFile instead of truncating it
+	OutputParser        string // Logfmt / JSON / Auto, emits LogEntry instead of raw lines
+	OutputLevel         string // minimum level emitted when OutputParser is set
+	OutputBatchInterval int    // milliseconds, emit output as arrays of lines at most once per interval
+	OutputBatchSize     int    // maximum lines per batch
+	OutputDropPolicy    string // Block / Oldest / Newest, applied when a batch is full
+	OutputBufferLines   int    // keep the last N lines for GetProcessOutput
+}
+
+type OutputLine struct {
+	Seq  int64 `json:"seq"`
+	Line any   `json:"line"` // string, or LogEntry when OutputParser is set
+}
+
+type ProcessOutput struct {
+	Lines     []OutputLine `json:"lines"`
+	LastSeq   int64        `json:"lastSeq"`
+	Truncated bool         `json:"truncated"` // some lines after the requested sequence are no longer buffered
+}
+
+type LogEntry struct {
+	Level   string         `json:"level"`
+	Time    string         `json:"time"`
+	Message string         `json:"message"`
+	Fields  map[string]any `json:"fields,omitempty"`
+	Raw     string         `json:"raw"`
+}
+
+type ProcessRecord struct {
+	Id               string   `json:"id"`
+	Path             string   `json:"path"`
+	Args             []string `json:"args"`
+	Pid              int      `json:"pid"`
+	Background       bool     `json:"background"`
+	KeepAlive        bool     `json:"keepAlive"`
+	WorkingDirectory string   `json:"workingDirectory"`
+	PidFile          string   `json:"pidFile"`
+	LogFile          string   `json:"logFile"`
+	StartTime        int64    `json:"startTime"` // unix milliseconds
+	EndTime          int64    `json:"endTime"`
+	Running          bool     `json:"running"`
+	ExitCode         int      `json:"exitCode"` // -1 while running or when unknown
+	Error            string   `json:"error,omitempty"`
+}
+
+type ProcessStatsResult struct {
+	Pid            int32   `json:"pid"`
+	CPUPercent     float64 `json:"cpuPercent"`
+	MemoryRSS      uint64  `json:"memoryRss"`
+	MemoryVMS      uint64  `json:"memoryVms"`
+	NumThreads     int32   `json:"numThreads"`
+	NumFDs         int32   `json:"numFds"`
+	NumConnections int     `json:"numConnections"`
+	Uptime         int64   `json:"uptime"` // seconds
+	ReadCount      uint64  `json:"readCount"`
+	WriteCount     uint64  `json:"writeCount"`
+	ReadBytes      uint64  `json:"readBytes"`
+	WriteBytes     uint64  `json:"writeBytes"`
+}
+
+type SupervisorOptions struct {
+	Exec           ExecOptions
+	MaxRestarts    int // restarts allowed within RestartWindow, 0 = unlimited
+	RestartWindow  int // seconds
+	BackoffInitial int // milliseconds
+	BackoffMax     int // milliseconds
+}
+
+type SupervisorInfo struct {
+	Id       string `json:"id"`
+	State    string `json:"state"` // starting / running / crashed / backing-off / stopped
+	Pid      int    `json:"pid"`
+	Restarts int    `json:"restarts"`
+	Delay    int64  `json:"delay,omitempty"` // milliseconds until the next restart
+	Error    string `json:"error,omitempty"`
+	Since    int64  `json:"since"` // unix milliseconds of the last state change
 }
 
 type Range struct {
@@ -50,9 +143,86 @@ type Range struct {
 	End   *int64
 }
 
+type RemoveOptions struct {
+	Trash bool   // move into data/.trash instead of deleting
+	Token string // sandbox token, the paths granted to its caller are allowed too
+}
+
+type TrashEntry struct {
+	Id           string `json:"id"`
+	Name         string `json:"name"`
+	OriginalPath string `json:"originalPath"`
+	IsDir        bool   `json:"isDir"`
+	Size         int64  `json:"size"`
+	DeletedAt    int64  `json:"deletedAt"` // unix milliseconds
+}
+
+type FileOptions struct {
+	Token string // sandbox token, the paths granted to its caller are allowed too
+}
+
 type IOOptions struct {
-	Mode  string // Binary / Text
-	Range string // "start-end" / "start-" / "-end"
+	Mode    string // Binary / Text
+	Range   string // "start-end" / "start-" / "-end"
+	Atomic  bool   // WriteFile: write to a temp file and rename it over the target (ignored for range writes)
+	Backups int    // WriteFile: keep this many previous versions as path.bak.1 ... path.bak.N
+	Token   string // sandbox token, the paths granted to its caller are allowed too
+}
+
+type FileHandleOptions struct {
+	Flag  string // r (default) / w / a / rw
+	Mode  string // Binary (default) / Text, encoding of chunk contents
+	Token string // sandbox token, the paths granted to its caller are allowed too
+}
+
+type FileChunk struct {
+	Data   string `json:"data"`
+	Offset int64  `json:"offset"` // offset after this chunk
+	EOF    bool   `json:"eof"`
+}
+
+type ArchiveOptions struct {
+	BaseDir string   // entry names are relative to this directory, defaults to the parent of each source
+	Include []string // globs a file must match one of, all files when empty
+	Exclude []string // globs for files and directories to leave out
+	Token   string   // sandbox token, the paths granted to its caller are allowed too
+}
+
+type ExtractOptions struct {
+	StripComponents int      // leading path elements removed from every entry name
+	Include         []string // globs matched against the stripped entry name, all entries when empty
+	FailFast        bool     // stop at the first entry that cannot be extracted instead of reporting all of them at the end
+	MaxSize         int64    // bytes, total extracted size limit (default 2 GiB), -1 for no limit
+	Event           string   // progress event, emitted with (progress, total) like Download
+	Token           string   // sandbox token, the paths granted to its caller are allowed too
+}
+
+type ListDirOptions struct {
+	Depth   int    // levels to descend, 0 or 1 lists direct children only, -1 is unlimited
+	Pattern string // glob matched against the entry name, or against the relative path when it contains "/"
+	Token   string // sandbox token, the paths granted to its caller are allowed too
+}
+
+type DirEntry struct {
+	Name      string `json:"name"`
+	Path      string `json:"path"` // relative to the listed directory, slash separated
+	Size      int64  `json:"size"`
+	IsDir     bool   `json:"isDir"`
+	IsSymlink bool   `json:"isSymlink"`
+	Target    string `json:"target,omitempty"` // symlink target
+	Mode      string `json:"mode"`             // e.g. -rw-r--r--
+	ModTime   int64  `json:"modTime"`          // unix milliseconds
+}
+
+type WatchOptions struct {
+	Recursive bool   // watch subdirectories, including ones created later
+	Debounce  int    // milliseconds, changes within this window are coalesced into one batch (default 100)
+	Token     string // sandbox token, the paths granted to its caller are allowed too
+}
+
+type WatchEvent struct {
+	Op   string `json:"op"`   // create / modify / remove / rename
+	Path string `json:"path"` // relative to Env.BasePath when inside it
 }
 
 type FlagResult struct {
@@ -77,6 +247,24 @@ type NetOptions struct {
 	Timeout int
 }
 
+type ExecResult struct {
+	Flag     bool   `json:"flag"`
+	Stdout   string `json:"stdout"`
+	Stderr   string `json:"stderr"`
+	ExitCode int    `json:"exitCode"`
+	Signal   string `json:"signal"`
+	Duration int64  `json:"duration"` // milliseconds
+	Error    string `json:"error"`
+}
+
+// RequestAttempt is emitted before a request is retried
+type RequestAttempt struct {
+	Attempt  int    `json:"attempt"`
+	Attempts int    `json:"attempts"`
+	Delay    int64  `json:"delay"` // milliseconds
+	Error    string `json:"error"`
+}
+
 type HTTPResult struct {
 	Flag    bool        `json:"flag"`
 	Status  int         `json:"status"`
@@ -85,14 +273,22 @@ type HTTPResult struct {
 }
 
 type AppConfig struct {
-	WindowStartState  int  `yaml:"windowStartState"`
-	WebviewGpuPolicy  int  `yaml:"webviewGpuPolicy"`
-	ContentProtection bool `yaml:"contentProtection"`
-	Width             int  `yaml:"width"`
-	Height            int  `yaml:"height"`
-	MultipleInstance  bool `yaml:"multipleInstance"`
-	RollingRelease    bool `yaml:"rollingRelease" default:"true"`
-	StartHidden       bool
+	WindowStartState   int                 `yaml:"windowStartState"`
+	WebviewGpuPolicy   int                 `yaml:"webviewGpuPolicy"`
+	ContentProtection  bool                `yaml:"contentProtection"`
+	Width              int                 `yaml:"width"`
+	Height             int                 `yaml:"height"`
+	MultipleInstance   bool                `yaml:"multipleInstance"`
+	RollingRelease     bool                `yaml:"rollingRelease" default:"true"`
+	SandboxRoots       []string            `yaml:"sandboxRoots"`       // directories bridge file operations may touch, default data
+	SandboxGrants      map[string][]string `yaml:"sandboxGrants"`      // extra paths allowed per plugin id
+	TrashRetentionDays int                 `yaml:"trashRetentionDays"` // days before trashed files are purged, default 30, negative keeps them
+	StartHidden        bool
+}
+
+type SandboxInfo struct {
+	Roots  []string            `json:"roots"`
+	Grants map[string][]string `json:"grants"`
 }
 
 type TrayContent struct {
@@ -102,6 +298,7 @@ type TrayContent struct {
 }
 
 type WriteTracker struct {
+	mu             sync.Mutex
 	Total          int64
 	Progress       int64
 	LastEmitted    int64
@@ -15,8 +15,9 @@ import (
 )
 
 type requestTransportKey struct {
-	Proxy    string
-	Insecure bool
+	Proxy     string // normalized, without credentials
+	ProxyAuth string
+	Insecure  bool
 }
 
 var requestTransportCache sync.Map
@@ -28,17 +29,68 @@ func resolvePath(path string) string {
 	return filepath.ToSlash(filepath.Clean(path))
 }
 
+// relativeToBasePath is the inverse of resolvePath for paths inside Env.BasePath, other
+// paths are returned as absolute slash separated paths
+func relativeToBasePath(path string) string {
+	rel, err := filepath.Rel(Env.BasePath, path)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return filepath.ToSlash(path)
+	}
+	return filepath.ToSlash(rel)
+}
+
 func requestProxy(proxyAddr string) func(*http.Request) (*url.URL, error) {
-	proxy := http.ProxyFromEnvironment
+	if proxyAddr == "" {
+		return http.ProxyFromEnvironment
+	}
 
-	if proxyAddr != "" {
-		proxyUrl, err := url.Parse(proxyAddr)
-		if err == nil {
-			proxy = http.ProxyURL(proxyUrl)
+	// an unusable proxy fails every request instead of silently connecting directly
+	proxyUrl, err := parseRequestProxy(proxyAddr)
+	if err != nil {
+		return func(*http.Request) (*url.URL, error) {
+			return nil, err
 		}
 	}
 
-	return proxy
+	return http.ProxyURL(proxyUrl)
+}
+
+// parseRequestProxy accepts http, https, socks5 and socks5h proxy URLs with optional
+// credentials, as well as the host:port, socks=host:port and socks://host:port forms
+// found in system proxy settings
+func parseRequestProxy(proxyAddr string) (*url.URL, error) {
+	proxyAddr = strings.TrimSpace(proxyAddr)
+	if rest, ok := strings.CutPrefix(proxyAddr, "socks="); ok {
+		proxyAddr = "socks5://" + rest
+	}
+	if !strings.Contains(proxyAddr, "://") {
+		proxyAddr = "http://" + proxyAddr
+	}
+
+	proxyUrl, err := url.Parse(proxyAddr)
+	if err != nil {
+		// the url.Error would repeat the address along with any password in it
+		var urlErr *url.Error
+		if errors.As(err, &urlErr) {
+			err = urlErr.Err
+		}
+		return nil, errors.New("invalid proxy: " + err.Error())
+	}
+
+	switch strings.ToLower(proxyUrl.Scheme) {
+	case "http", "https", "socks5", "socks5h":
+		proxyUrl.Scheme = strings.ToLower(proxyUrl.Scheme)
+	case "socks":
+		proxyUrl.Scheme = "socks5"
+	default:
+		return nil, errors.New("unsupported proxy scheme: " + proxyUrl.Scheme)
+	}
+
+	if proxyUrl.Host == "" {
+		return nil, errors.New("invalid proxy: missing host")
+	}
+
+	return proxyUrl, nil
 }
 
 func requestTimeout(timeout int) time.Duration {
@@ -75,6 +127,11 @@ func requestTransport(options RequestOptions) *http.Transport {
 		Proxy:    options.Proxy,
 		Insecure: options.Insecure,
 	}
+	if proxyUrl, err := parseRequestProxy(options.Proxy); err == nil && options.Proxy != "" {
+		key.ProxyAuth = proxyUrl.User.String()
+		proxyUrl.User = nil
+		key.Proxy = proxyUrl.String()
+	}
 
 	if value, ok := requestTransportCache.Load(key); ok {
 		return value.(*http.Transport)
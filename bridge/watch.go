@@ -0,0 +1,263 @@
+package bridge
+
+import (
+	"io/fs"
+	"log"
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+	"github.com/wailsapp/wails/v2/pkg/runtime"
+)
+
+const (
+	WatchCreate = "create"
+	WatchModify = "modify"
+	WatchRemove = "remove"
+	WatchRename = "rename"
+)
+
+var (
+	watchCounter atomic.Uint64
+	watchMap     sync.Map
+)
+
+func (a *App) WatchPath(path string, event string, options WatchOptions) FlagResult {
+	log.Printf("WatchPath [recursive=%v]: %s %s", options.Recursive, path, event)
+
+	if event == "" {
+		return FlagResult{false, "event is required"}
+	}
+
+	fullPath, err := sandboxPath(path, options.Token)
+	if err != nil {
+		return FlagResult{false, err.Error()}
+	}
+
+	stat, err := os.Stat(fullPath)
+	if err != nil {
+		return FlagResult{false, err.Error()}
+	}
+
+	watcher, err := startWatcher(fullPath, stat.IsDir(), options, event, func(events []WatchEvent) {
+		runtime.EventsEmit(a.Ctx, event, events)
+	})
+	if err != nil {
+		return FlagResult{false, err.Error()}
+	}
+
+	id := strconv.FormatUint(watchCounter.Add(1), 10)
+	watchMap.Store(id, watcher)
+
+	return FlagResult{true, id}
+}
+
+// watchTarget describes what one watcher reports on
+type watchTarget struct {
+	root      string // the directory watched
+	file      string // set when a single file is watched, the only path reported then
+	recursive bool
+	debounce  time.Duration
+}
+
+// startWatcher watches fullPath and hands every debounced batch of changes to emit. A
+// single file is watched through its directory: editors, and WriteFile with Atomic,
+// save by renaming a new file over the old one, which would end a watch on the file
+// itself after the first save.
+func startWatcher(fullPath string, isDir bool, options WatchOptions, event string, emit func([]WatchEvent)) (*fsnotify.Watcher, error) {
+	target := watchTarget{
+		root:      fullPath,
+		recursive: options.Recursive && isDir,
+		debounce:  100 * time.Millisecond,
+	}
+	if !isDir {
+		target.root, target.file = filepath.Dir(fullPath), filepath.Clean(fullPath)
+	}
+	if options.Debounce > 0 {
+		target.debounce = time.Duration(options.Debounce) * time.Millisecond
+	}
+
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return nil, err
+	}
+
+	if target.recursive {
+		err = addWatchTree(watcher, target.root, nil)
+	} else {
+		err = watcher.Add(target.root)
+	}
+	if err != nil {
+		watcher.Close()
+		return nil, err
+	}
+
+	go runWatcher(watcher, target, event, emit)
+
+	return watcher, nil
+}
+
+func (a *App) UnwatchPath(id string) FlagResult {
+	log.Printf("UnwatchPath: %s", id)
+
+	val, ok := watchMap.LoadAndDelete(id)
+	if !ok {
+		return FlagResult{false, "watcher not found"}
+	}
+
+	if err := val.(*fsnotify.Watcher).Close(); err != nil {
+		return FlagResult{false, err.Error()}
+	}
+
+	return FlagResult{true, "Success"}
+}
+
+// CloseWatchers closes every watcher. It is called when the frontend is (re)loaded,
+// which drops every listener to their events.
+func CloseWatchers() {
+	watchMap.Range(func(key, val any) bool {
+		if watchMap.CompareAndDelete(key, val) {
+			val.(*fsnotify.Watcher).Close()
+		}
+		return true
+	})
+}
+
+// runWatcher coalesces raw notifications per path and emits them as one batch once the
+// debounce window that started with the first pending change has elapsed. With file set
+// only changes to that file are reported. It returns when the watcher is closed.
+func runWatcher(watcher *fsnotify.Watcher, target watchTarget, event string, emit func([]WatchEvent)) {
+	pending := make(map[string]string)
+	var order []string
+	var timer <-chan time.Time
+
+	// a file replaced by a rename shows up as created, yet it is still the file watched
+	fileExists := target.file != ""
+
+	queue := func(path string, op string) {
+		prev, exists := pending[path]
+		if !exists {
+			order = append(order, path)
+		}
+		switch {
+		case prev == WatchCreate && op == WatchModify:
+			// still a new file as far as the listener is concerned
+		case prev == WatchCreate && op == WatchRemove:
+			pending[path] = ""
+		default:
+			pending[path] = op
+		}
+		if timer == nil {
+			timer = time.After(target.debounce)
+		}
+	}
+
+	for {
+		select {
+		case ev, ok := <-watcher.Events:
+			if !ok {
+				return
+			}
+			op := watchOp(ev)
+			if op == "" {
+				continue
+			}
+
+			// the watched directory itself was removed or renamed, which ends its watch;
+			// pick it up again if something has already taken its place
+			if (op == WatchRemove || op == WatchRename) && filepath.Clean(ev.Name) == filepath.Clean(target.root) {
+				if stat, err := os.Stat(target.root); err == nil && stat.IsDir() {
+					if err := watcher.Add(target.root); err != nil {
+						log.Printf("WatchPath [%s]: %v", event, err)
+					}
+				}
+			}
+
+			if target.file != "" {
+				if filepath.Clean(ev.Name) != target.file {
+					continue
+				}
+				switch op {
+				case WatchCreate:
+					if fileExists {
+						op = WatchModify
+					}
+					fileExists = true
+				case WatchRemove, WatchRename:
+					fileExists = false
+				}
+			}
+			queue(ev.Name, op)
+
+			if target.recursive && op == WatchCreate {
+				if stat, err := os.Lstat(ev.Name); err == nil && stat.IsDir() {
+					// files created before the new directory was watched would otherwise go unnoticed
+					err := addWatchTree(watcher, ev.Name, func(path string) {
+						queue(path, WatchCreate)
+					})
+					if err != nil {
+						log.Printf("WatchPath [%s]: %v", event, err)
+					}
+				}
+			}
+		case err, ok := <-watcher.Errors:
+			if !ok {
+				return
+			}
+			log.Printf("WatchPath [%s]: %v", event, err)
+		case <-timer:
+			timer = nil
+
+			events := make([]WatchEvent, 0, len(order))
+			for _, path := range order {
+				if op := pending[path]; op != "" {
+					events = append(events, WatchEvent{Op: op, Path: relativeToBasePath(path)})
+				}
+			}
+			pending = make(map[string]string)
+			order = nil
+
+			if len(events) > 0 {
+				emit(events)
+			}
+		}
+	}
+}
+
+// addWatchTree watches root and every directory below it, reporting everything found
+// below root to found when it is not nil
+func addWatchTree(watcher *fsnotify.Watcher, root string, found func(path string)) error {
+	return filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			if path == root {
+				return err
+			}
+			return nil
+		}
+		if path != root && found != nil {
+			found(path)
+		}
+		if d.IsDir() {
+			return watcher.Add(path)
+		}
+		return nil
+	})
+}
+
+func watchOp(ev fsnotify.Event) string {
+	switch {
+	case ev.Has(fsnotify.Remove):
+		return WatchRemove
+	case ev.Has(fsnotify.Rename):
+		return WatchRename
+	case ev.Has(fsnotify.Create):
+		return WatchCreate
+	case ev.Has(fsnotify.Write):
+		return WatchModify
+	}
+	return ""
+}
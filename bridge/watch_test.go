@@ -0,0 +1,157 @@
+package bridge
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// watchEvents starts a watcher on path, returning a channel fed with every batch it emits
+func watchEvents(t *testing.T, path string, options WatchOptions) <-chan []WatchEvent {
+	t.Helper()
+
+	stat, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	batches := make(chan []WatchEvent, 16)
+	watcher, err := startWatcher(path, stat.IsDir(), options, "test", func(events []WatchEvent) {
+		batches <- events
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { watcher.Close() })
+
+	return batches
+}
+
+func nextBatch(t *testing.T, batches <-chan []WatchEvent) []WatchEvent {
+	t.Helper()
+	select {
+	case events := <-batches:
+		return events
+	case <-time.After(5 * time.Second):
+		t.Fatal("no events")
+		return nil
+	}
+}
+
+func TestWatchFileAcrossAtomicSaves(t *testing.T) {
+	dir := useSandbox(t)
+	path := filepath.Join(dir, "data", "profile.yaml")
+	os.MkdirAll(filepath.Dir(path), os.ModePerm)
+	os.WriteFile(path, []byte("v0"), 0644)
+
+	batches := watchEvents(t, path, WatchOptions{Debounce: 20})
+
+	for i, content := range []string{"v1", "v2", "v3"} {
+		// saved the way editors and WriteFile with Atomic do, by renaming over the file
+		if err := writeFileAtomic(path, []byte(content)); err != nil {
+			t.Fatal(err)
+		}
+		events := nextBatch(t, batches)
+		if len(events) != 1 || events[0].Path != "data/profile.yaml" || events[0].Op != WatchModify {
+			t.Fatalf("save %d: events = %+v", i+1, events)
+		}
+	}
+
+	// siblings in the same directory are not reported
+	os.WriteFile(filepath.Join(dir, "data", "other.yaml"), []byte("x"), 0644)
+	os.Remove(path)
+	if events := nextBatch(t, batches); len(events) != 1 || events[0].Op != WatchRemove {
+		t.Fatalf("events = %+v", events)
+	}
+
+	os.WriteFile(path, []byte("v4"), 0644)
+	if events := nextBatch(t, batches); len(events) != 1 || events[0].Op != WatchCreate {
+		t.Fatalf("events after recreating = %+v", events)
+	}
+}
+
+func TestWatchDebounceCoalesces(t *testing.T) {
+	dir := useSandbox(t)
+	root := filepath.Join(dir, "data")
+	os.MkdirAll(root, os.ModePerm)
+	os.WriteFile(filepath.Join(root, "kept.txt"), []byte("0"), 0644)
+
+	batches := watchEvents(t, root, WatchOptions{Debounce: 200})
+
+	// a burst within the debounce window arrives as one batch with one event per path
+	for i := range 5 {
+		os.WriteFile(filepath.Join(root, "kept.txt"), []byte{byte('1' + i)}, 0644)
+	}
+	os.WriteFile(filepath.Join(root, "new.txt"), []byte("x"), 0644)
+	os.WriteFile(filepath.Join(root, "new.txt"), []byte("y"), 0644)
+	os.WriteFile(filepath.Join(root, "gone.txt"), []byte("z"), 0644)
+	os.Remove(filepath.Join(root, "gone.txt"))
+
+	events := nextBatch(t, batches)
+	want := map[string]string{"data/kept.txt": WatchModify, "data/new.txt": WatchCreate}
+	if len(events) != len(want) {
+		t.Fatalf("events = %+v", events)
+	}
+	for _, ev := range events {
+		if want[ev.Path] != ev.Op {
+			t.Errorf("events = %+v", events)
+		}
+	}
+
+	select {
+	case events := <-batches:
+		t.Errorf("burst was split, extra batch %+v", events)
+	case <-time.After(400 * time.Millisecond):
+	}
+}
+
+func TestWatchRecursiveNewDirectories(t *testing.T) {
+	dir := useSandbox(t)
+	root := filepath.Join(dir, "data")
+	os.MkdirAll(root, os.ModePerm)
+
+	batches := watchEvents(t, root, WatchOptions{Recursive: true, Debounce: 50})
+
+	// created together, so the nested file may exist before its directory is watched
+	os.MkdirAll(filepath.Join(root, "a", "b"), os.ModePerm)
+	os.WriteFile(filepath.Join(root, "a", "b", "early.txt"), []byte("x"), 0644)
+
+	seen := make(map[string]string)
+	deadline := time.After(5 * time.Second)
+	for seen["data/a/b/early.txt"] == "" {
+		select {
+		case events := <-batches:
+			for _, ev := range events {
+				seen[ev.Path] = ev.Op
+			}
+		case <-deadline:
+			t.Fatalf("events = %+v", seen)
+		}
+	}
+	if seen["data/a"] != WatchCreate || seen["data/a/b/early.txt"] != WatchCreate {
+		t.Errorf("events = %+v", seen)
+	}
+
+	// the new directories are watched from now on
+	os.WriteFile(filepath.Join(root, "a", "b", "late.txt"), []byte("x"), 0644)
+	if events := nextBatch(t, batches); len(events) != 1 || events[0].Path != "data/a/b/late.txt" || events[0].Op != WatchCreate {
+		t.Errorf("events = %+v", events)
+	}
+}
+
+func TestCloseWatchers(t *testing.T) {
+	dir := useSandbox(t)
+	os.MkdirAll(filepath.Join(dir, "data"), os.ModePerm)
+
+	a := &App{}
+	result := a.WatchPath("data", "closed", WatchOptions{})
+	if !result.Flag {
+		t.Fatal(result.Data)
+	}
+
+	CloseWatchers()
+	if result := a.UnwatchPath(result.Data); result.Flag {
+		t.Error("watcher outlived CloseWatchers")
+	}
+}
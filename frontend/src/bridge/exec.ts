@@ -1,25 +1,124 @@
 import * as Bridge from '@wails/go/bridge/App'
-import { EventsOn, EventsOff } from '@wails/runtime/runtime'
+import { bridge } from '@wails/go/models'
+import { EventsOn, EventsOff, EventsEmit } from '@wails/runtime/runtime'
 
 import { sampleID } from '@/utils'
 
 interface ExecOptions {
+  Id?: string
+  KeepAlive?: boolean
   PidFile?: string
   LogFile?: string
   Env?: Record<string, any>
   StopOutputKeyword?: string
   WorkingDirectory?: string
+  Timeout?: number
+  CancelId?: string
+  Stdin?: boolean
+  LogMaxSize?: number
+  LogMaxBackups?: number
+  LogCompress?: boolean
+  LogRotateOnStart?: boolean
+  OutputParser?: 'Logfmt' | 'JSON' | 'Auto'
+  OutputLevel?: 'debug' | 'info' | 'warning' | 'error'
+  OutputBatchInterval?: number
+  OutputBatchSize?: number
+  OutputDropPolicy?: 'Block' | 'Oldest' | 'Newest'
+  OutputBufferLines?: number
   env?: Record<string, any>
   stopOutputKeyword?: string
 }
 
+interface LogEntry {
+  level: string
+  time: string
+  message: string
+  fields?: Record<string, any>
+  raw: string
+}
+
+// A line is a LogEntry when OutputParser is set, and lines arrive as arrays when
+// OutputBatchInterval is set
+type OutputLine = string | LogEntry
+type ExecOutput = OutputLine | OutputLine[]
+
+interface ProcessRecord {
+  id: string
+  path: string
+  args: string[]
+  pid: number
+  background: boolean
+  keepAlive: boolean
+  workingDirectory: string
+  pidFile: string
+  logFile: string
+  startTime: number
+  endTime: number
+  running: boolean
+  exitCode: number
+  error?: string
+}
+
+interface ProcessOutput {
+  lines: { seq: number; line: OutputLine }[]
+  lastSeq: number
+  truncated: boolean
+}
+
+interface ProcessStats {
+  pid: number
+  cpuPercent: number
+  memoryRss: number
+  memoryVms: number
+  numThreads: number
+  numFds: number
+  numConnections: number
+  uptime: number
+  readCount: number
+  writeCount: number
+  readBytes: number
+  writeBytes: number
+}
+
+interface SupervisorOptions extends ExecOptions {
+  MaxRestarts?: number
+  RestartWindow?: number
+  BackoffInitial?: number
+  BackoffMax?: number
+}
+
+interface SupervisorInfo {
+  id: string
+  state: 'starting' | 'running' | 'crashed' | 'backing-off' | 'stopped'
+  pid: number
+  restarts: number
+  delay?: number
+  error?: string
+  since: number
+}
+
 const mergeExecOptions = (options: ExecOptions) => {
   const mergedExecOpts = {
+    Id: options.Id ?? '',
+    KeepAlive: options.KeepAlive ?? false,
     PidFile: options.PidFile ?? '',
     LogFile: options.LogFile ?? '',
     Env: options.Env ?? options.env ?? {},
     StopOutputKeyword: options.StopOutputKeyword ?? options.stopOutputKeyword ?? '',
     WorkingDirectory: options.WorkingDirectory ?? '',
+    Timeout: options.Timeout ?? 0,
+    CancelId: options.CancelId ?? '',
+    Stdin: options.Stdin ?? false,
+    LogMaxSize: options.LogMaxSize ?? 0,
+    LogMaxBackups: options.LogMaxBackups ?? 0,
+    LogCompress: options.LogCompress ?? false,
+    LogRotateOnStart: options.LogRotateOnStart ?? false,
+    OutputParser: options.OutputParser ?? '',
+    OutputLevel: options.OutputLevel ?? '',
+    OutputBatchInterval: options.OutputBatchInterval ?? 0,
+    OutputBatchSize: options.OutputBatchSize ?? 0,
+    OutputDropPolicy: options.OutputDropPolicy ?? '',
+    OutputBufferLines: options.OutputBufferLines ?? 0,
   }
   return mergedExecOpts
 }
@@ -32,10 +131,16 @@ export const Exec = async (path: string, args: string[], options: ExecOptions =
   return data
 }
 
+export const ExecWithResult = async (path: string, args: string[], options: ExecOptions = {}) => {
+  return Bridge.ExecWithResult(path, args, mergeExecOptions(options))
+}
+
+export const ExecCancel = (cancelId: string) => EventsEmit(cancelId)
+
 export const ExecBackground = async (
   path: string,
   args: string[] = [],
-  onOut?: (out: string) => void,
+  onOut?: (out: ExecOutput) => void,
   onEnd?: (out: string) => void,
   options: ExecOptions = {},
 ) => {
@@ -84,6 +189,32 @@ export const ProcessMemory = async (pid: number) => {
   return Number(data)
 }
 
+export const ProcessStats = async (pid: number) => {
+  const { flag, data } = await Bridge.ProcessStats(pid)
+  if (!flag) {
+    throw data
+  }
+  return JSON.parse(data) as ProcessStats
+}
+
+export const StartProcessSampler = async (
+  pid: number,
+  onStats: (stats: ProcessStats) => void,
+  interval = 1000,
+) => {
+  const event = sampleID()
+  EventsOn(event, onStats)
+  const { flag, data } = await Bridge.StartProcessSampler(pid, event, interval)
+  if (!flag) {
+    EventsOff(event)
+    throw data
+  }
+  return async () => {
+    EventsOff(event)
+    await Bridge.StopProcessSampler(event)
+  }
+}
+
 export const KillProcess = async (pid: number, timeout = 10) => {
   const { flag, data } = await Bridge.KillProcess(pid, timeout)
   if (!flag) {
@@ -91,3 +222,110 @@ export const KillProcess = async (pid: number, timeout = 10) => {
   }
   return data
 }
+
+export const ListProcesses = async () => {
+  const { flag, data } = await Bridge.ListProcesses()
+  if (!flag) {
+    throw data
+  }
+  return JSON.parse(data) as ProcessRecord[]
+}
+
+export const GetProcess = async (id: string) => {
+  const { flag, data } = await Bridge.GetProcess(id)
+  if (!flag) {
+    throw data
+  }
+  return JSON.parse(data) as ProcessRecord
+}
+
+export const GetProcessOutput = async (id: string, sinceSeq = 0) => {
+  const { flag, data } = await Bridge.GetProcessOutput(id, sinceSeq)
+  if (!flag) {
+    throw data
+  }
+  return JSON.parse(data) as ProcessOutput
+}
+
+export const WriteProcessStdin = async (
+  id: string,
+  data: string,
+  mode: 'Binary' | 'Text' = 'Text',
+) => {
+  const { flag, data: res } = await Bridge.WriteProcessStdin(id, data, mode)
+  if (!flag) {
+    throw res
+  }
+  return res
+}
+
+export const CloseProcessStdin = async (id: string) => {
+  const { flag, data } = await Bridge.CloseProcessStdin(id)
+  if (!flag) {
+    throw data
+  }
+  return data
+}
+
+export const StartSupervised = async (
+  id: string,
+  path: string,
+  args: string[] = [],
+  onOut?: (out: ExecOutput) => void,
+  onState?: (info: SupervisorInfo) => void,
+  options: SupervisorOptions = {},
+) => {
+  const outEvent = (onOut && sampleID()) || ''
+  const stateEvent = sampleID()
+
+  EventsOn(stateEvent, (info: SupervisorInfo) => {
+    if (info.state === 'stopped') {
+      outEvent && EventsOff(outEvent)
+      EventsOff(stateEvent)
+    }
+    onState?.(info)
+  })
+
+  if (outEvent) {
+    EventsOn(outEvent, onOut!)
+  }
+
+  const supervisorOptions = bridge.SupervisorOptions.createFrom({
+    Exec: mergeExecOptions(options),
+    MaxRestarts: options.MaxRestarts ?? 0,
+    RestartWindow: options.RestartWindow ?? 0,
+    BackoffInitial: options.BackoffInitial ?? 0,
+    BackoffMax: options.BackoffMax ?? 0,
+  })
+
+  const { flag, data } = await Bridge.StartSupervised(
+    id,
+    path,
+    args,
+    outEvent,
+    stateEvent,
+    supervisorOptions,
+  )
+  if (!flag) {
+    outEvent && EventsOff(outEvent)
+    EventsOff(stateEvent)
+    throw data
+  }
+  return data
+}
+
+export const StopSupervised = async (id: string, timeout = 10) => {
+  const { flag, data } = await Bridge.StopSupervised(id, timeout)
+  if (!flag) {
+    throw data
+  }
+  return data
+}
+
+export const SupervisorStatus = async (id: string) => {
+  const { flag, data } = await Bridge.SupervisorStatus(id)
+  if (!flag) {
+    throw data
+  }
+  return JSON.parse(data) as SupervisorInfo
+}
@@ -1,14 +1,23 @@
 import * as Bridge from '@wails/go/bridge/App'
+import { EventsOn, EventsOff } from '@wails/runtime/runtime'
+
+import { sampleID } from '@/utils'
 
 interface IOOptions {
   Mode?: 'Binary' | 'Text'
   Range?: string
+  Atomic?: boolean
+  Backups?: number
+  Token?: string
 }
 
 export const WriteFile = async (path: string, content: string, options: IOOptions = {}) => {
   const { flag, data } = await Bridge.WriteFile(path, content, {
     Mode: 'Text',
     Range: '',
+    Atomic: true,
+    Backups: 0,
+    Token: '',
     ...options,
   })
   if (!flag) {
@@ -18,85 +27,174 @@ export const WriteFile = async (path: string, content: string, options: IOOption
 }
 
 export const ReadFile = async (path: string, options: IOOptions = {}) => {
-  const { flag, data } = await Bridge.ReadFile(path, { Mode: 'Text', Range: '', ...options })
+  const { flag, data } = await Bridge.ReadFile(path, {
+    Mode: 'Text',
+    Range: '',
+    Atomic: false,
+    Backups: 0,
+    Token: '',
+    ...options,
+  })
   if (!flag) {
     throw data
   }
   return data
 }
 
-export const MoveFile = async (source: string, target: string) => {
-  const { flag, data } = await Bridge.MoveFile(source, target)
+interface FileOptions {
+  Token?: string
+}
+
+export const MoveFile = async (source: string, target: string, options: FileOptions = {}) => {
+  const { flag, data } = await Bridge.MoveFile(source, target, { Token: '', ...options })
   if (!flag) {
     throw data
   }
   return data
 }
 
-export const RemoveFile = async (path: string) => {
-  const { flag, data } = await Bridge.RemoveFile(path)
+interface RemoveOptions {
+  Trash?: boolean
+  Token?: string
+}
+
+export const RemoveFile = async (path: string, options: RemoveOptions = {}) => {
+  const { flag, data } = await Bridge.RemoveFile(path, { Trash: false, Token: '', ...options })
   if (!flag) {
     throw data
   }
   return data
 }
 
-export const CopyFile = async (source: string, target: string) => {
-  const { flag, data } = await Bridge.CopyFile(source, target)
+export interface TrashEntry {
+  id: string
+  name: string
+  originalPath: string
+  isDir: boolean
+  size: number
+  deletedAt: number
+}
+
+export const ListTrash = async () => {
+  const { flag, data } = await Bridge.ListTrash()
+  if (!flag) {
+    throw data
+  }
+  return JSON.parse(data) as TrashEntry[]
+}
+
+export const RestoreFromTrash = async (id: string, options: FileOptions = {}) => {
+  const { flag, data } = await Bridge.RestoreFromTrash(id, { Token: '', ...options })
   if (!flag) {
     throw data
   }
   return data
 }
 
-export const FileExists = async (path: string) => {
-  const { flag, data } = await Bridge.FileExists(path)
+export const EmptyTrash = async (id = '') => {
+  const { flag, data } = await Bridge.EmptyTrash(id)
+  if (!flag) {
+    throw data
+  }
+  return data
+}
+
+export const CopyFile = async (source: string, target: string, options: FileOptions = {}) => {
+  const { flag, data } = await Bridge.CopyFile(source, target, { Token: '', ...options })
+  if (!flag) {
+    throw data
+  }
+  return data
+}
+
+export const FileExists = async (path: string, options: FileOptions = {}) => {
+  const { flag, data } = await Bridge.FileExists(path, { Token: '', ...options })
   if (!flag) {
     throw data
   }
   return data === 'true'
 }
 
-export const FileSHA256 = async (path: string) => {
-  const { flag, data } = await Bridge.FileSHA256(path)
+export const FileSHA256 = async (path: string, options: FileOptions = {}) => {
+  const { flag, data } = await Bridge.FileSHA256(path, { Token: '', ...options })
+  if (!flag) {
+    throw data
+  }
+  return data
+}
+
+export const FileHash = async (
+  path: string,
+  algo: 'md5' | 'sha1' | 'sha256' | 'sha512' | 'blake3' = 'sha256',
+  options: FileOptions = {},
+) => {
+  const { flag, data } = await Bridge.FileHash(path, algo, { Token: '', ...options })
   if (!flag) {
     throw data
   }
   return data
 }
 
-export const AbsolutePath = async (path: string) => {
-  const { flag, data } = await Bridge.AbsolutePath(path)
+export const VerifyChecksumFile = async (
+  checksumsPath: string,
+  targetPath: string,
+  options: FileOptions = {},
+) => {
+  const { flag, data } = await Bridge.VerifyChecksumFile(checksumsPath, targetPath, {
+    Token: '',
+    ...options,
+  })
   if (!flag) {
     throw data
   }
   return data
 }
 
-export const MakeDir = async (path: string) => {
-  const { flag, data } = await Bridge.MakeDir(path)
+export const AbsolutePath = async (path: string, options: FileOptions = {}) => {
+  const { flag, data } = await Bridge.AbsolutePath(path, { Token: '', ...options })
   if (!flag) {
     throw data
   }
   return data
 }
 
-export const ReadDir = async (path: string) => {
-  const { flag, data } = await Bridge.ReadDir(path)
+export const MakeDir = async (path: string, options: FileOptions = {}) => {
+  const { flag, data } = await Bridge.MakeDir(path, { Token: '', ...options })
   if (!flag) {
     throw data
   }
   return data
-    .split('|')
-    .filter((v) => v)
-    .map((v) => {
-      const [name, size, isDir] = v.split(',') as [string, string, string]
-      return { name, size: Number(size), isDir: isDir === 'true' }
-    })
 }
 
-export const OpenDir = async (path: string) => {
-  const { flag, data } = await Bridge.OpenDir(path)
+interface ListDirOptions {
+  Depth?: number
+  Pattern?: string
+  Token?: string
+}
+
+export interface DirEntry {
+  name: string
+  path: string
+  size: number
+  isDir: boolean
+  isSymlink: boolean
+  target?: string
+  mode: string
+  modTime: number
+}
+
+export const ListDir = async (path: string, options: ListDirOptions = {}) => {
+  const { flag, data } = await Bridge.ListDir(path, { Depth: 1, Pattern: '', Token: '', ...options })
+  if (!flag) {
+    throw data
+  }
+  return JSON.parse(data) as DirEntry[]
+}
+
+export const ReadDir = async (path: string, options: FileOptions = {}) => ListDir(path, options)
+
+export const OpenDir = async (path: string, options: FileOptions = {}) => {
+  const { flag, data } = await Bridge.OpenDir(path, { Token: '', ...options })
   if (!flag) {
     throw data
   }
@@ -111,26 +209,189 @@ export const OpenURI = async (uri: string) => {
   return data
 }
 
-export const UnzipZIPFile = async (path: string, output: string) => {
-  const { flag, data } = await Bridge.UnzipZIPFile(path, output)
+interface ArchiveOptions {
+  BaseDir?: string
+  Include?: string[]
+  Exclude?: string[]
+  Token?: string
+}
+
+export const ZipFiles = async (sources: string[], output: string, options: ArchiveOptions = {}) => {
+  const { flag, data } = await Bridge.ZipFiles(sources, output, {
+    BaseDir: '',
+    Include: [],
+    Exclude: [],
+    Token: '',
+    ...options,
+  })
   if (!flag) {
     throw data
   }
   return data
 }
 
-export const UnzipGZFile = async (path: string, output: string) => {
-  const { flag, data } = await Bridge.UnzipGZFile(path, output)
+export const TarGzFiles = async (sources: string[], output: string, options: ArchiveOptions = {}) => {
+  const { flag, data } = await Bridge.TarGzFiles(sources, output, {
+    BaseDir: '',
+    Include: [],
+    Exclude: [],
+    Token: '',
+    ...options,
+  })
   if (!flag) {
     throw data
   }
   return data
 }
 
-export const UnzipTarGZFile = async (path: string, output: string) => {
-  const { flag, data } = await Bridge.UnzipTarGZFile(path, output)
+interface ExtractOptions {
+  StripComponents?: number
+  Include?: string[]
+  FailFast?: boolean
+  MaxSize?: number
+  Token?: string
+}
+
+export const ExtractArchive = async (
+  path: string,
+  output: string,
+  options: ExtractOptions = {},
+  progress?: (progress: number, total: number) => void,
+) => {
+  const event = (progress && sampleID()) || ''
+  if (event) {
+    EventsOn(event, progress!)
+  }
+  const { flag, data } = await Bridge.ExtractArchive(path, output, {
+    StripComponents: 0,
+    Include: [],
+    FailFast: false,
+    MaxSize: 0,
+    Token: '',
+    ...options,
+    Event: event,
+  })
+  if (event) {
+    EventsOff(event)
+  }
   if (!flag) {
     throw data
   }
   return data
 }
+
+export const UnzipZIPFile = async (path: string, output: string, options: FileOptions = {}) => {
+  const { flag, data } = await Bridge.UnzipZIPFile(path, output, { Token: '', ...options })
+  if (!flag) {
+    throw data
+  }
+  return data
+}
+
+export const UnzipGZFile = async (path: string, output: string, options: FileOptions = {}) => {
+  const { flag, data } = await Bridge.UnzipGZFile(path, output, { Token: '', ...options })
+  if (!flag) {
+    throw data
+  }
+  return data
+}
+
+export const UnzipTarGZFile = async (path: string, output: string, options: FileOptions = {}) => {
+  const { flag, data } = await Bridge.UnzipTarGZFile(path, output, { Token: '', ...options })
+  if (!flag) {
+    throw data
+  }
+  return data
+}
+
+interface WatchOptions {
+  Recursive?: boolean
+  Debounce?: number
+  Token?: string
+}
+
+export interface WatchEvent {
+  op: 'create' | 'modify' | 'remove' | 'rename'
+  path: string
+}
+
+export const WatchPath = async (
+  path: string,
+  onChange: (events: WatchEvent[]) => void,
+  options: WatchOptions = {},
+) => {
+  const event = sampleID()
+  EventsOn(event, onChange)
+  const { flag, data } = await Bridge.WatchPath(path, event, {
+    Recursive: false,
+    Debounce: 100,
+    Token: '',
+    ...options,
+  })
+  if (!flag) {
+    EventsOff(event)
+    throw data
+  }
+  return async () => {
+    EventsOff(event)
+    await Bridge.UnwatchPath(data)
+  }
+}
+
+interface FileHandleOptions {
+  Flag?: 'r' | 'w' | 'a' | 'rw'
+  Mode?: 'Binary' | 'Text'
+  Token?: string
+}
+
+export interface FileChunk {
+  data: string
+  offset: number
+  eof: boolean
+}
+
+export const OpenFileHandle = async (path: string, options: FileHandleOptions = {}) => {
+  const { flag, data } = await Bridge.OpenFileHandle(path, { Flag: 'r', Mode: 'Binary', Token: '', ...options })
+  if (!flag) {
+    throw data
+  }
+  return data
+}
+
+export const ReadChunk = async (id: string, size = 1024 * 1024) => {
+  const { flag, data } = await Bridge.ReadChunk(id, size)
+  if (!flag) {
+    throw data
+  }
+  return JSON.parse(data) as FileChunk
+}
+
+export const WriteChunk = async (id: string, content: string) => {
+  const { flag, data } = await Bridge.WriteChunk(id, content)
+  if (!flag) {
+    throw data
+  }
+  return Number(data)
+}
+
+export const CloseFileHandle = async (id: string) => {
+  const { flag, data } = await Bridge.CloseFileHandle(id)
+  if (!flag) {
+    throw data
+  }
+  return data
+}
+
+export interface SandboxInfo {
+  roots: string[]
+  grants: Record<string, string[]>
+}
+
+export const GetSandbox = async () => {
+  const { flag, data } = await Bridge.GetSandbox()
+  if (!flag) {
+    throw data
+  }
+  return JSON.parse(data) as SandboxInfo
+}
+
@@ -23,6 +23,13 @@ type StreamEvent =
       id?: string
       retry?: number
     }
+  | {
+      type: 'retry'
+      attempt: number
+      attempts: number
+      delay: number
+      error: string
+    }
   | {
       type: 'done'
     }
@@ -46,14 +53,31 @@ interface Request {
     CancelId?: string
     FileField?: string
     Sha256?: string
+    Checksum?: string
     Stream?: string
+    Segments?: number
+    Cache?: boolean
+    Retries?: number
+    RetryBackoff?: number
+    RetryStatus?: number[]
+    RetryEvent?: string
+    Token?: string
   }
 }
 
+interface RequestAttempt {
+  attempt: number
+  attempts: number
+  delay: number // milliseconds
+  error: string
+}
+
 interface Response<T = any> {
   status: number
   headers: Record<string, string | string[]>
   body: T
+  // with the Cache option, the cached body is unchanged since the last request
+  notModified: boolean
 }
 
 const mergeNetOptions = (options: NetOptions = {}): Required<NetOptions> => ({
@@ -71,7 +95,15 @@ const mergeRequestOptions = async (options: Request['options']) => {
     CancelId: '',
     FileField: 'file',
     Sha256: '',
+    Checksum: '',
     Stream: '',
+    Segments: 0,
+    Cache: false,
+    Retries: 0,
+    RetryBackoff: 1000, // 1 second
+    RetryStatus: [],
+    RetryEvent: '',
+    Token: '',
     ...options,
   }
   return mergedReqOpts
@@ -121,13 +153,26 @@ const transformResponse = <T = any>(
   const transformedHeaders = transformResponseHeaders(headers)
   const transformedBody = transformResponseBody<T>(body, transformedHeaders)
 
-  return { status, headers: transformedHeaders, body: transformedBody }
+  return {
+    status,
+    headers: transformedHeaders,
+    body: transformedBody,
+    notModified: status === 304,
+  }
 }
 
 interface RequestWithProgressOptions {
   Method?: Request['method']
+  // called before a retry with the attempt about to be made
+  onRetry?: (attempt: RequestAttempt) => void
 }
 
+/**
+ * Retries are not reported through the progress callback: it keeps receiving only
+ * (progress, total), so existing handlers never mistake an attempt for progress. Each retry
+ * is delivered to onRetry instead, on an event of its own (RequestOptions.RetryEvent).
+ * Streamed Requests report retries as { type: 'retry' } messages on their Stream event.
+ */
 const requestWithProgress = (fnName: 'Download' | 'Upload') => {
   return async (
     url: Request['url'],
@@ -136,13 +181,17 @@ const requestWithProgress = (fnName: 'Download' | 'Upload') => {
     progress?: (progress: number, total: number) => void,
     options: Request['options'] & RequestWithProgressOptions = {},
   ) => {
+    const { Method, onRetry, ...requestOptions } = options
+
+    const retryEvent = (onRetry && sampleID()) || ''
+
     const [_headers, , _options] = await transformRequest(headers, null, {
       Timeout: 20 * 60, // 20 minutes
-      ...options,
+      ...requestOptions,
+      RetryEvent: retryEvent,
     })
 
-    const method =
-      options.Method ?? { Download: RequestMethod.Get, Upload: RequestMethod.Post }[fnName]
+    const method = Method ?? { Download: RequestMethod.Get, Upload: RequestMethod.Post }[fnName]
 
     const progressEvent = (progress && sampleID()) || ''
 
@@ -150,6 +199,10 @@ const requestWithProgress = (fnName: 'Download' | 'Upload') => {
       EventsOn(progressEvent, progress!)
     }
 
+    if (retryEvent) {
+      EventsOn(retryEvent, onRetry!)
+    }
+
     const {
       flag,
       status,
@@ -168,6 +221,10 @@ const requestWithProgress = (fnName: 'Download' | 'Upload') => {
       EventsOff(progressEvent)
     }
 
+    if (retryEvent) {
+      EventsOff(retryEvent)
+    }
+
     if (!flag) throw respBody
 
     return transformResponse(status, respHeaders, respBody)
@@ -262,6 +319,7 @@ export const Requests = async <T = any>(options: RequestWithAutoTransform) => {
     status,
     headers: transformedHeaders,
     body: transformBody ? transformResponseBody<T>(respBody, transformedHeaders) : (respBody as T),
+    notModified: status === 304,
   }
 }
 
@@ -0,0 +1,138 @@
+import { EventsOnce } from '@wails/runtime/runtime'
+
+/**
+ * The app's sandbox token never passes through window.go, window.WailsInvoke or any other
+ * global a plugin could wrap. Go sends it on the onSandboxSession event once the page has
+ * loaded, before any plugin runs, and calls carrying it are posted over the native message
+ * channel captured when this module is imported. Deliberately not exported from '@/bridge',
+ * which plugins receive as window.Plugins.
+ */
+
+type Result = { flag: boolean; data: string }
+
+const w = window as any
+const stringify = JSON.stringify
+const parse = JSON.parse
+const channel = w.chrome?.webview ?? w.webkit?.messageHandlers?.external
+const post: (message: string) => void = channel ? channel.postMessage.bind(channel) : w.WailsInvoke
+
+const pending = new Map<string, (result: Result) => void>()
+let callbackID = 0
+
+const wailsCallback = w.wails.Callback
+w.wails.Callback = (message: string) => {
+  const { callbackid, error, result } = parse(message)
+  const resolve = pending.get(callbackid)
+  if (!resolve) {
+    return wailsCallback(message)
+  }
+  pending.delete(callbackid)
+  resolve(error ? { flag: false, data: String(error) } : result)
+}
+
+let appToken = ''
+
+const session = new Promise<void>((resolve) => {
+  EventsOnce('onSandboxSession', (token: string) => {
+    appToken = token
+    resolve()
+  })
+})
+
+/**
+ * Resolves once Go has handed this page its app token
+ */
+export const waitForSandboxSession = () => session
+
+/**
+ * Calls a binding with the JSON of its argument list. args runs once the token is known and
+ * assembles the JSON by hand, so the token is never inside an object or array whose
+ * prototype a plugin could have patched.
+ */
+const call = async (method: string, args: () => string) => {
+  await session
+  const { flag, data } = await new Promise<Result>((resolve) => {
+    const id = 'sandbox-' + ++callbackID
+    pending.set(id, resolve)
+    post(
+      'C{"name":' +
+        stringify('bridge.App.' + method) +
+        ',"args":' +
+        args() +
+        ',"callbackID":' +
+        stringify(id) +
+        '}',
+    )
+  })
+  if (!flag) {
+    throw data
+  }
+  return data
+}
+
+const token = () => stringify(appToken)
+
+// the arguments before the options, as an unterminated JSON list
+const list = (...values: string[]) => stringify(values).slice(0, -1)
+
+// options as JSON with the app token appended last, where it wins over any Token before it
+const withToken = (options: object) => {
+  const json = stringify(options)
+  return json.slice(0, -1) + (json === '{}' ? '' : ',') + '"Token":' + token() + '}'
+}
+
+/**
+ * Issues the token a plugin passes as the Token option to use its sandboxGrants from user.yaml
+ */
+export const getPluginSandboxToken = (id: string) =>
+  call('SandboxToken', () => '[' + token() + ',' + stringify(id) + ']')
+
+const files = {
+  ReadFile: (path: string, options: { Mode?: 'Binary' | 'Text' } = {}) =>
+    call('ReadFile', () => {
+      const io = { Mode: 'Text', Range: '', Atomic: false, Backups: 0, ...options }
+      return list(path) + ',' + withToken(io) + ']'
+    }),
+  WriteFile: (path: string, content: string) =>
+    call('WriteFile', () => {
+      const io = { Mode: 'Text', Range: '', Atomic: true, Backups: 0 }
+      return list(path, content) + ',' + withToken(io) + ']'
+    }),
+  MoveFile: (source: string, target: string) =>
+    call('MoveFile', () => list(source, target) + ',' + withToken({}) + ']'),
+  CopyFile: (source: string, target: string) =>
+    call('CopyFile', () => list(source, target) + ',' + withToken({}) + ']'),
+  RemoveFile: (path: string) =>
+    call('RemoveFile', () => list(path) + ',' + withToken({ Trash: false }) + ']'),
+  FileExists: async (path: string) =>
+    (await call('FileExists', () => list(path) + ',' + withToken({}) + ']')) === 'true',
+  OpenDir: (path: string) => call('OpenDir', () => list(path) + ',' + withToken({}) + ']'),
+}
+
+/**
+ * File operations carrying the app token, handed to the callback of withSandboxGrant
+ */
+export type SandboxFiles = typeof files
+
+/**
+ * Temporarily allows paths outside the sandbox roots to the file operations given to fn
+ */
+export const withSandboxGrant = async <T>(
+  paths: string[],
+  fn: (files: SandboxFiles) => Promise<T>,
+) => {
+  const granted: string[] = []
+  try {
+    for (const path of paths) {
+      await call('GrantSandboxPath', () => '[' + token() + ',' + stringify(path) + ']')
+      granted.push(path)
+    }
+    return await fn(files)
+  } finally {
+    for (const path of granted) {
+      await call('RevokeSandboxPath', () => '[' + token() + ',' + stringify(path) + ']').catch(
+        () => {},
+      )
+    }
+  }
+}
@@ -2,43 +2,71 @@
 // This file is automatically generated. DO NOT EDIT
 import {bridge} from '../models';
 
-export function AbsolutePath(arg1:string):Promise<bridge.FlagResult>;
+export function AbsolutePath(arg1:string,arg2:bridge.FileOptions):Promise<bridge.FlagResult>;
+
+export function CloseFileHandle(arg1:string):Promise<bridge.FlagResult>;
 
 export function CloseMMDB(arg1:string,arg2:string):Promise<bridge.FlagResult>;
 
-export function CopyFile(arg1:string,arg2:string):Promise<bridge.FlagResult>;
+export function CloseProcessStdin(arg1:string):Promise<bridge.FlagResult>;
+
+export function CopyFile(arg1:string,arg2:string,arg3:bridge.FileOptions):Promise<bridge.FlagResult>;
 
 export function Download(arg1:string,arg2:string,arg3:string,arg4:Record<string, string>,arg5:string,arg6:bridge.RequestOptions):Promise<bridge.HTTPResult>;
 
+export function EmptyTrash(arg1:string):Promise<bridge.FlagResult>;
+
 export function Exec(arg1:string,arg2:Array<string>,arg3:bridge.ExecOptions):Promise<bridge.FlagResult>;
 
 export function ExecBackground(arg1:string,arg2:Array<string>,arg3:string,arg4:string,arg5:bridge.ExecOptions):Promise<bridge.FlagResult>;
 
+export function ExecWithResult(arg1:string,arg2:Array<string>,arg3:bridge.ExecOptions):Promise<bridge.ExecResult>;
+
 export function ExitApp():Promise<void>;
 
-export function FileExists(arg1:string):Promise<bridge.FlagResult>;
+export function ExtractArchive(arg1:string,arg2:string,arg3:bridge.ExtractOptions):Promise<bridge.FlagResult>;
 
-export function FileSHA256(arg1:string):Promise<bridge.FlagResult>;
+export function FileExists(arg1:string,arg2:bridge.FileOptions):Promise<bridge.FlagResult>;
+
+export function FileHash(arg1:string,arg2:string,arg3:bridge.FileOptions):Promise<bridge.FlagResult>;
+
+export function FileSHA256(arg1:string,arg2:bridge.FileOptions):Promise<bridge.FlagResult>;
 
 export function GetEnv(arg1:string):Promise<any>;
 
 export function GetInterfaces():Promise<bridge.FlagResult>;
 
+export function GetProcess(arg1:string):Promise<bridge.FlagResult>;
+
+export function GetProcessOutput(arg1:string,arg2:number):Promise<bridge.FlagResult>;
+
+export function GetSandbox():Promise<bridge.FlagResult>;
+
 export function GetSystemProxy():Promise<bridge.FlagResult>;
 
 export function GetSystemProxyBypass():Promise<bridge.FlagResult>;
 
+export function GrantSandboxPath(arg1:string,arg2:string):Promise<bridge.FlagResult>;
+
 export function IsStartup():Promise<boolean>;
 
 export function KillProcess(arg1:number,arg2:number):Promise<bridge.FlagResult>;
 
+export function ListDir(arg1:string,arg2:bridge.ListDirOptions):Promise<bridge.FlagResult>;
+
+export function ListProcesses():Promise<bridge.FlagResult>;
+
 export function ListServer():Promise<bridge.FlagResult>;
 
-export function MakeDir(arg1:string):Promise<bridge.FlagResult>;
+export function ListTrash():Promise<bridge.FlagResult>;
 
-export function MoveFile(arg1:string,arg2:string):Promise<bridge.FlagResult>;
+export function MakeDir(arg1:string,arg2:bridge.FileOptions):Promise<bridge.FlagResult>;
 
-export function OpenDir(arg1:string):Promise<bridge.FlagResult>;
+export function MoveFile(arg1:string,arg2:string,arg3:bridge.FileOptions):Promise<bridge.FlagResult>;
+
+export function OpenDir(arg1:string,arg2:bridge.FileOptions):Promise<bridge.FlagResult>;
+
+export function OpenFileHandle(arg1:string,arg2:bridge.FileHandleOptions):Promise<bridge.FlagResult>;
 
 export function OpenMMDB(arg1:string,arg2:string):Promise<bridge.FlagResult>;
 
@@ -48,39 +76,63 @@ export function ProcessInfo(arg1:number):Promise<bridge.FlagResult>;
 
 export function ProcessMemory(arg1:number):Promise<bridge.FlagResult>;
 
+export function ProcessStats(arg1:number):Promise<bridge.FlagResult>;
+
 export function QueryMMDB(arg1:string,arg2:string,arg3:string):Promise<bridge.FlagResult>;
 
-export function ReadDir(arg1:string):Promise<bridge.FlagResult>;
+export function ReadChunk(arg1:string,arg2:number):Promise<bridge.FlagResult>;
+
+export function ReadDir(arg1:string,arg2:bridge.FileOptions):Promise<bridge.FlagResult>;
 
 export function ReadFile(arg1:string,arg2:bridge.IOOptions):Promise<bridge.FlagResult>;
 
-export function RemoveFile(arg1:string):Promise<bridge.FlagResult>;
+export function RemoveFile(arg1:string,arg2:bridge.RemoveOptions):Promise<bridge.FlagResult>;
 
 export function Requests(arg1:string,arg2:string,arg3:Record<string, string>,arg4:string,arg5:bridge.RequestOptions):Promise<bridge.HTTPResult>;
 
 export function RestartApp():Promise<bridge.FlagResult>;
 
+export function RestoreFromTrash(arg1:string,arg2:bridge.FileOptions):Promise<bridge.FlagResult>;
+
+export function RevokeSandboxPath(arg1:string,arg2:string):Promise<bridge.FlagResult>;
+
+export function SandboxToken(arg1:string,arg2:string):Promise<bridge.FlagResult>;
+
 export function SetSystemDNS(arg1:string,arg2:Array<string>):Promise<bridge.FlagResult>;
 
 export function SetSystemProxy(arg1:boolean,arg2:string,arg3:string,arg4:string,arg5:Array<string>):Promise<bridge.FlagResult>;
 
 export function ShowMainWindow():Promise<void>;
 
+export function StartProcessSampler(arg1:number,arg2:string,arg3:number):Promise<bridge.FlagResult>;
+
 export function StartServer(arg1:string,arg2:string,arg3:bridge.ServerOptions):Promise<bridge.FlagResult>;
 
+export function StartSupervised(arg1:string,arg2:string,arg3:Array<string>,arg4:string,arg5:string,arg6:bridge.SupervisorOptions):Promise<bridge.FlagResult>;
+
+export function StopProcessSampler(arg1:string):Promise<bridge.FlagResult>;
+
 export function StopServer(arg1:string):Promise<bridge.FlagResult>;
 
+export function StopSupervised(arg1:string,arg2:number):Promise<bridge.FlagResult>;
+
+export function SupervisorStatus(arg1:string):Promise<bridge.FlagResult>;
+
+export function TarGzFiles(arg1:Array<string>,arg2:string,arg3:bridge.ArchiveOptions):Promise<bridge.FlagResult>;
+
 export function TcpPing(arg1:string,arg2:bridge.NetOptions):Promise<bridge.FlagResult>;
 
 export function TcpRequest(arg1:string,arg2:string,arg3:bridge.NetOptions):Promise<bridge.FlagResult>;
 
 export function UdpRequest(arg1:string,arg2:string,arg3:bridge.NetOptions):Promise<bridge.FlagResult>;
 
-export function UnzipGZFile(arg1:string,arg2:string):Promise<bridge.FlagResult>;
+export function UnwatchPath(arg1:string):Promise<bridge.FlagResult>;
+
+export function UnzipGZFile(arg1:string,arg2:string,arg3:bridge.FileOptions):Promise<bridge.FlagResult>;
 
-export function UnzipTarGZFile(arg1:string,arg2:string):Promise<bridge.FlagResult>;
+export function UnzipTarGZFile(arg1:string,arg2:string,arg3:bridge.FileOptions):Promise<bridge.FlagResult>;
 
-export function UnzipZIPFile(arg1:string,arg2:string):Promise<bridge.FlagResult>;
+export function UnzipZIPFile(arg1:string,arg2:string,arg3:bridge.FileOptions):Promise<bridge.FlagResult>;
 
 export function UpdateTray(arg1:bridge.TrayContent):Promise<void>;
 
@@ -90,4 +142,14 @@ export function UpdateTrayMenus(arg1:Array<bridge.MenuItem>):Promise<void>;
 
 export function Upload(arg1:string,arg2:string,arg3:string,arg4:Record<string, string>,arg5:string,arg6:bridge.RequestOptions):Promise<bridge.HTTPResult>;
 
+export function VerifyChecksumFile(arg1:string,arg2:string,arg3:bridge.FileOptions):Promise<bridge.FlagResult>;
+
+export function WatchPath(arg1:string,arg2:string,arg3:bridge.WatchOptions):Promise<bridge.FlagResult>;
+
+export function WriteChunk(arg1:string,arg2:string):Promise<bridge.FlagResult>;
+
 export function WriteFile(arg1:string,arg2:string,arg3:bridge.IOOptions):Promise<bridge.FlagResult>;
+
+export function WriteProcessStdin(arg1:string,arg2:string,arg3:string):Promise<bridge.FlagResult>;
+
+export function ZipFiles(arg1:Array<string>,arg2:string,arg3:bridge.ArchiveOptions):Promise<bridge.FlagResult>;
@@ -2,22 +2,34 @@
 // Cynhyrchwyd y ffeil hon yn awtomatig. PEIDIWCH Â MODIWL
 // This file is automatically generated. DO NOT EDIT
 
-export function AbsolutePath(arg1) {
-  return window['go']['bridge']['App']['AbsolutePath'](arg1);
+export function AbsolutePath(arg1, arg2) {
+  return window['go']['bridge']['App']['AbsolutePath'](arg1, arg2);
+}
+
+export function CloseFileHandle(arg1) {
+  return window['go']['bridge']['App']['CloseFileHandle'](arg1);
 }
 
 export function CloseMMDB(arg1, arg2) {
   return window['go']['bridge']['App']['CloseMMDB'](arg1, arg2);
 }
 
-export function CopyFile(arg1, arg2) {
-  return window['go']['bridge']['App']['CopyFile'](arg1, arg2);
+export function CloseProcessStdin(arg1) {
+  return window['go']['bridge']['App']['CloseProcessStdin'](arg1);
+}
+
+export function CopyFile(arg1, arg2, arg3) {
+  return window['go']['bridge']['App']['CopyFile'](arg1, arg2, arg3);
 }
 
 export function Download(arg1, arg2, arg3, arg4, arg5, arg6) {
   return window['go']['bridge']['App']['Download'](arg1, arg2, arg3, arg4, arg5, arg6);
 }
 
+export function EmptyTrash(arg1) {
+  return window['go']['bridge']['App']['EmptyTrash'](arg1);
+}
+
 export function Exec(arg1, arg2, arg3) {
   return window['go']['bridge']['App']['Exec'](arg1, arg2, arg3);
 }
@@ -26,16 +38,28 @@ export function ExecBackground(arg1, arg2, arg3, arg4, arg5) {
   return window['go']['bridge']['App']['ExecBackground'](arg1, arg2, arg3, arg4, arg5);
 }
 
+export function ExecWithResult(arg1, arg2, arg3) {
+  return window['go']['bridge']['App']['ExecWithResult'](arg1, arg2, arg3);
+}
+
 export function ExitApp() {
   return window['go']['bridge']['App']['ExitApp']();
 }
 
-export function FileExists(arg1) {
-  return window['go']['bridge']['App']['FileExists'](arg1);
+export function ExtractArchive(arg1, arg2, arg3) {
+  return window['go']['bridge']['App']['ExtractArchive'](arg1, arg2, arg3);
 }
 
-export function FileSHA256(arg1) {
-  return window['go']['bridge']['App']['FileSHA256'](arg1);
+export function FileExists(arg1, arg2) {
+  return window['go']['bridge']['App']['FileExists'](arg1, arg2);
+}
+
+export function FileHash(arg1, arg2, arg3) {
+  return window['go']['bridge']['App']['FileHash'](arg1, arg2, arg3);
+}
+
+export function FileSHA256(arg1, arg2) {
+  return window['go']['bridge']['App']['FileSHA256'](arg1, arg2);
 }
 
 export function GetEnv(arg1) {
@@ -46,6 +70,18 @@ export function GetInterfaces() {
   return window['go']['bridge']['App']['GetInterfaces']();
 }
 
+export function GetProcess(arg1) {
+  return window['go']['bridge']['App']['GetProcess'](arg1);
+}
+
+export function GetProcessOutput(arg1, arg2) {
+  return window['go']['bridge']['App']['GetProcessOutput'](arg1, arg2);
+}
+
+export function GetSandbox() {
+  return window['go']['bridge']['App']['GetSandbox']();
+}
+
 export function GetSystemProxy() {
   return window['go']['bridge']['App']['GetSystemProxy']();
 }
@@ -54,6 +90,10 @@ export function GetSystemProxyBypass() {
   return window['go']['bridge']['App']['GetSystemProxyBypass']();
 }
 
+export function GrantSandboxPath(arg1, arg2) {
+  return window['go']['bridge']['App']['GrantSandboxPath'](arg1, arg2);
+}
+
 export function IsStartup() {
   return window['go']['bridge']['App']['IsStartup']();
 }
@@ -62,20 +102,36 @@ export function KillProcess(arg1, arg2) {
   return window['go']['bridge']['App']['KillProcess'](arg1, arg2);
 }
 
+export function ListDir(arg1, arg2) {
+  return window['go']['bridge']['App']['ListDir'](arg1, arg2);
+}
+
+export function ListProcesses() {
+  return window['go']['bridge']['App']['ListProcesses']();
+}
+
 export function ListServer() {
   return window['go']['bridge']['App']['ListServer']();
 }
 
-export function MakeDir(arg1) {
-  return window['go']['bridge']['App']['MakeDir'](arg1);
+export function ListTrash() {
+  return window['go']['bridge']['App']['ListTrash']();
 }
 
-export function MoveFile(arg1, arg2) {
-  return window['go']['bridge']['App']['MoveFile'](arg1, arg2);
+export function MakeDir(arg1, arg2) {
+  return window['go']['bridge']['App']['MakeDir'](arg1, arg2);
 }
 
-export function OpenDir(arg1) {
-  return window['go']['bridge']['App']['OpenDir'](arg1);
+export function MoveFile(arg1, arg2, arg3) {
+  return window['go']['bridge']['App']['MoveFile'](arg1, arg2, arg3);
+}
+
+export function OpenDir(arg1, arg2) {
+  return window['go']['bridge']['App']['OpenDir'](arg1, arg2);
+}
+
+export function OpenFileHandle(arg1, arg2) {
+  return window['go']['bridge']['App']['OpenFileHandle'](arg1, arg2);
 }
 
 export function OpenMMDB(arg1, arg2) {
@@ -94,20 +150,28 @@ export function ProcessMemory(arg1) {
   return window['go']['bridge']['App']['ProcessMemory'](arg1);
 }
 
+export function ProcessStats(arg1) {
+  return window['go']['bridge']['App']['ProcessStats'](arg1);
+}
+
 export function QueryMMDB(arg1, arg2, arg3) {
   return window['go']['bridge']['App']['QueryMMDB'](arg1, arg2, arg3);
 }
 
-export function ReadDir(arg1) {
-  return window['go']['bridge']['App']['ReadDir'](arg1);
+export function ReadChunk(arg1, arg2) {
+  return window['go']['bridge']['App']['ReadChunk'](arg1, arg2);
+}
+
+export function ReadDir(arg1, arg2) {
+  return window['go']['bridge']['App']['ReadDir'](arg1, arg2);
 }
 
 export function ReadFile(arg1, arg2) {
   return window['go']['bridge']['App']['ReadFile'](arg1, arg2);
 }
 
-export function RemoveFile(arg1) {
-  return window['go']['bridge']['App']['RemoveFile'](arg1);
+export function RemoveFile(arg1, arg2) {
+  return window['go']['bridge']['App']['RemoveFile'](arg1, arg2);
 }
 
 export function Requests(arg1, arg2, arg3, arg4, arg5) {
@@ -118,6 +182,18 @@ export function RestartApp() {
   return window['go']['bridge']['App']['RestartApp']();
 }
 
+export function RestoreFromTrash(arg1, arg2) {
+  return window['go']['bridge']['App']['RestoreFromTrash'](arg1, arg2);
+}
+
+export function RevokeSandboxPath(arg1, arg2) {
+  return window['go']['bridge']['App']['RevokeSandboxPath'](arg1, arg2);
+}
+
+export function SandboxToken(arg1, arg2) {
+  return window['go']['bridge']['App']['SandboxToken'](arg1, arg2);
+}
+
 export function SetSystemDNS(arg1, arg2) {
   return window['go']['bridge']['App']['SetSystemDNS'](arg1, arg2);
 }
@@ -130,14 +206,38 @@ export function ShowMainWindow() {
   return window['go']['bridge']['App']['ShowMainWindow']();
 }
 
+export function StartProcessSampler(arg1, arg2, arg3) {
+  return window['go']['bridge']['App']['StartProcessSampler'](arg1, arg2, arg3);
+}
+
 export function StartServer(arg1, arg2, arg3) {
   return window['go']['bridge']['App']['StartServer'](arg1, arg2, arg3);
 }
 
+export function StartSupervised(arg1, arg2, arg3, arg4, arg5, arg6) {
+  return window['go']['bridge']['App']['StartSupervised'](arg1, arg2, arg3, arg4, arg5, arg6);
+}
+
+export function StopProcessSampler(arg1) {
+  return window['go']['bridge']['App']['StopProcessSampler'](arg1);
+}
+
 export function StopServer(arg1) {
   return window['go']['bridge']['App']['StopServer'](arg1);
 }
 
+export function StopSupervised(arg1, arg2) {
+  return window['go']['bridge']['App']['StopSupervised'](arg1, arg2);
+}
+
+export function SupervisorStatus(arg1) {
+  return window['go']['bridge']['App']['SupervisorStatus'](arg1);
+}
+
+export function TarGzFiles(arg1, arg2, arg3) {
+  return window['go']['bridge']['App']['TarGzFiles'](arg1, arg2, arg3);
+}
+
 export function TcpPing(arg1, arg2) {
   return window['go']['bridge']['App']['TcpPing'](arg1, arg2);
 }
@@ -150,16 +250,20 @@ export function UdpRequest(arg1, arg2, arg3) {
   return window['go']['bridge']['App']['UdpRequest'](arg1, arg2, arg3);
 }
 
-export function UnzipGZFile(arg1, arg2) {
-  return window['go']['bridge']['App']['UnzipGZFile'](arg1, arg2);
+export function UnwatchPath(arg1) {
+  return window['go']['bridge']['App']['UnwatchPath'](arg1);
+}
+
+export function UnzipGZFile(arg1, arg2, arg3) {
+  return window['go']['bridge']['App']['UnzipGZFile'](arg1, arg2, arg3);
 }
 
-export function UnzipTarGZFile(arg1, arg2) {
-  return window['go']['bridge']['App']['UnzipTarGZFile'](arg1, arg2);
+export function UnzipTarGZFile(arg1, arg2, arg3) {
+  return window['go']['bridge']['App']['UnzipTarGZFile'](arg1, arg2, arg3);
 }
 
-export function UnzipZIPFile(arg1, arg2) {
-  return window['go']['bridge']['App']['UnzipZIPFile'](arg1, arg2);
+export function UnzipZIPFile(arg1, arg2, arg3) {
+  return window['go']['bridge']['App']['UnzipZIPFile'](arg1, arg2, arg3);
 }
 
 export function UpdateTray(arg1) {
@@ -178,6 +282,26 @@ export function Upload(arg1, arg2, arg3, arg4, arg5, arg6) {
   return window['go']['bridge']['App']['Upload'](arg1, arg2, arg3, arg4, arg5, arg6);
 }
 
+export function VerifyChecksumFile(arg1, arg2, arg3) {
+  return window['go']['bridge']['App']['VerifyChecksumFile'](arg1, arg2, arg3);
+}
+
+export function WatchPath(arg1, arg2, arg3) {
+  return window['go']['bridge']['App']['WatchPath'](arg1, arg2, arg3);
+}
+
+export function WriteChunk(arg1, arg2) {
+  return window['go']['bridge']['App']['WriteChunk'](arg1, arg2);
+}
+
 export function WriteFile(arg1, arg2, arg3) {
   return window['go']['bridge']['App']['WriteFile'](arg1, arg2, arg3);
 }
+
+export function WriteProcessStdin(arg1, arg2, arg3) {
+  return window['go']['bridge']['App']['WriteProcessStdin'](arg1, arg2, arg3);
+}
+
+export function ZipFiles(arg1, arg2, arg3) {
+  return window['go']['bridge']['App']['ZipFiles'](arg1, arg2, arg3);
+}
@@ -1,11 +1,44 @@
 export namespace bridge {
 	
+	export class ArchiveOptions {
+	    BaseDir: string;
+	    Include: string[];
+	    Exclude: string[];
+	    Token: string;
+	
+	    static createFrom(source: any = {}) {
+	        return new ArchiveOptions(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.BaseDir = source["BaseDir"];
+	        this.Include = source["Include"];
+	        this.Exclude = source["Exclude"];
+	        this.Token = source["Token"];
+	    }
+	}
 	export class ExecOptions {
+	    Id: string;
+	    KeepAlive: boolean;
 	    PidFile: string;
 	    LogFile: string;
 	    StopOutputKeyword: string;
 	    WorkingDirectory: string;
 	    Env: Record<string, string>;
+	    Timeout: number;
+	    CancelId: string;
+	    Stdin: boolean;
+	    LogMaxSize: number;
+	    LogMaxBackups: number;
+	    LogCompress: boolean;
+	    LogRotateOnStart: boolean;
+	    OutputParser: string;
+	    OutputLevel: string;
+	    OutputBatchInterval: number;
+	    OutputBatchSize: number;
+	    OutputDropPolicy: string;
+	    OutputBufferLines: number;
 	
 	    static createFrom(source: any = {}) {
 	        return new ExecOptions(source);
@@ -13,11 +46,100 @@ export namespace bridge {
 	
 	    constructor(source: any = {}) {
 	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.Id = source["Id"];
+	        this.KeepAlive = source["KeepAlive"];
 	        this.PidFile = source["PidFile"];
 	        this.LogFile = source["LogFile"];
 	        this.StopOutputKeyword = source["StopOutputKeyword"];
 	        this.WorkingDirectory = source["WorkingDirectory"];
 	        this.Env = source["Env"];
+	        this.Timeout = source["Timeout"];
+	        this.CancelId = source["CancelId"];
+	        this.Stdin = source["Stdin"];
+	        this.LogMaxSize = source["LogMaxSize"];
+	        this.LogMaxBackups = source["LogMaxBackups"];
+	        this.LogCompress = source["LogCompress"];
+	        this.LogRotateOnStart = source["LogRotateOnStart"];
+	        this.OutputParser = source["OutputParser"];
+	        this.OutputLevel = source["OutputLevel"];
+	        this.OutputBatchInterval = source["OutputBatchInterval"];
+	        this.OutputBatchSize = source["OutputBatchSize"];
+	        this.OutputDropPolicy = source["OutputDropPolicy"];
+	        this.OutputBufferLines = source["OutputBufferLines"];
+	    }
+	}
+	export class ExecResult {
+	    flag: boolean;
+	    stdout: string;
+	    stderr: string;
+	    exitCode: number;
+	    signal: string;
+	    duration: number;
+	    error: string;
+	
+	    static createFrom(source: any = {}) {
+	        return new ExecResult(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.flag = source["flag"];
+	        this.stdout = source["stdout"];
+	        this.stderr = source["stderr"];
+	        this.exitCode = source["exitCode"];
+	        this.signal = source["signal"];
+	        this.duration = source["duration"];
+	        this.error = source["error"];
+	    }
+	}
+	export class ExtractOptions {
+	    StripComponents: number;
+	    Include: string[];
+	    FailFast: boolean;
+	    MaxSize: number;
+	    Event: string;
+	    Token: string;
+	
+	    static createFrom(source: any = {}) {
+	        return new ExtractOptions(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.StripComponents = source["StripComponents"];
+	        this.Include = source["Include"];
+	        this.FailFast = source["FailFast"];
+	        this.MaxSize = source["MaxSize"];
+	        this.Event = source["Event"];
+	        this.Token = source["Token"];
+	    }
+	}
+	export class FileHandleOptions {
+	    Flag: string;
+	    Mode: string;
+	    Token: string;
+	
+	    static createFrom(source: any = {}) {
+	        return new FileHandleOptions(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.Flag = source["Flag"];
+	        this.Mode = source["Mode"];
+	        this.Token = source["Token"];
+	    }
+	}
+	export class FileOptions {
+	    Token: string;
+	
+	    static createFrom(source: any = {}) {
+	        return new FileOptions(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.Token = source["Token"];
 	    }
 	}
 	export class FlagResult {
@@ -55,6 +177,9 @@ export namespace bridge {
 	export class IOOptions {
 	    Mode: string;
 	    Range: string;
+	    Atomic: boolean;
+	    Backups: number;
+	    Token: string;
 	
 	    static createFrom(source: any = {}) {
 	        return new IOOptions(source);
@@ -64,6 +189,25 @@ export namespace bridge {
 	        if ('string' === typeof source) source = JSON.parse(source);
 	        this.Mode = source["Mode"];
 	        this.Range = source["Range"];
+	        this.Atomic = source["Atomic"];
+	        this.Backups = source["Backups"];
+	        this.Token = source["Token"];
+	    }
+	}
+	export class ListDirOptions {
+	    Depth: number;
+	    Pattern: string;
+	    Token: string;
+	
+	    static createFrom(source: any = {}) {
+	        return new ListDirOptions(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.Depth = source["Depth"];
+	        this.Pattern = source["Pattern"];
+	        this.Token = source["Token"];
 	    }
 	}
 	export class MenuItem {
@@ -124,6 +268,20 @@ export namespace bridge {
 	        this.Timeout = source["Timeout"];
 	    }
 	}
+	export class RemoveOptions {
+	    Trash: boolean;
+	    Token: string;
+	
+	    static createFrom(source: any = {}) {
+	        return new RemoveOptions(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.Trash = source["Trash"];
+	        this.Token = source["Token"];
+	    }
+	}
 	export class RequestOptions {
 	    Proxy: string;
 	    Insecure: boolean;
@@ -132,7 +290,15 @@ export namespace bridge {
 	    CancelId: string;
 	    FileField: string;
 	    Sha256: string;
+	    Checksum: string;
 	    Stream: string;
+	    Segments: number;
+	    Cache: boolean;
+	    Retries: number;
+	    RetryBackoff: number;
+	    RetryStatus: number[];
+	    RetryEvent: string;
+	    Token: string;
 	
 	    static createFrom(source: any = {}) {
 	        return new RequestOptions(source);
@@ -147,7 +313,15 @@ export namespace bridge {
 	        this.CancelId = source["CancelId"];
 	        this.FileField = source["FileField"];
 	        this.Sha256 = source["Sha256"];
+	        this.Checksum = source["Checksum"];
 	        this.Stream = source["Stream"];
+	        this.Segments = source["Segments"];
+	        this.Cache = source["Cache"];
+	        this.Retries = source["Retries"];
+	        this.RetryBackoff = source["RetryBackoff"];
+	        this.RetryStatus = source["RetryStatus"];
+	        this.RetryEvent = source["RetryEvent"];
+	        this.Token = source["Token"];
 	    }
 	}
 	export class ServerOptions {
@@ -178,6 +352,44 @@ export namespace bridge {
 	        this.MaxUploadSize = source["MaxUploadSize"];
 	    }
 	}
+	export class SupervisorOptions {
+	    Exec: ExecOptions;
+	    MaxRestarts: number;
+	    RestartWindow: number;
+	    BackoffInitial: number;
+	    BackoffMax: number;
+	
+	    static createFrom(source: any = {}) {
+	        return new SupervisorOptions(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.Exec = this.convertValues(source["Exec"], ExecOptions);
+	        this.MaxRestarts = source["MaxRestarts"];
+	        this.RestartWindow = source["RestartWindow"];
+	        this.BackoffInitial = source["BackoffInitial"];
+	        this.BackoffMax = source["BackoffMax"];
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
 	export class TrayContent {
 	    icon?: string;
 	    title?: string;
@@ -194,6 +406,22 @@ export namespace bridge {
 	        this.tooltip = source["tooltip"];
 	    }
 	}
+	export class WatchOptions {
+	    Recursive: boolean;
+	    Debounce: number;
+	    Token: string;
+	
+	    static createFrom(source: any = {}) {
+	        return new WatchOptions(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.Recursive = source["Recursive"];
+	        this.Debounce = source["Debounce"];
+	        this.Token = source["Token"];
+	    }
+	}
 
 }
 
@@ -1,6 +1,7 @@
 import { ref } from 'vue'
 
 import { IsStartup } from '@/bridge'
+import { waitForSandboxSession } from '@/bridge/sandbox'
 import * as Stores from '@/stores'
 import { message, sleep } from '@/utils'
 
@@ -26,6 +27,8 @@ export const useAppBootstrap = () => {
   }
 
   const initialize = async () => {
+    // no plugin may run before the app holds its sandbox token
+    await waitForSandboxSession()
     await envStore.setupEnv()
 
     await Promise.all([
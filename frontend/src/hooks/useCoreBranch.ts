@@ -114,7 +114,8 @@ export const useCoreBranch = (isAlpha = false) => {
         },
         {
           CancelId: downloadCacheFile,
-          Sha256: asset.digest.slice(7),
+          Checksum: asset.digest,
+          Segments: 4,
         },
       )
 
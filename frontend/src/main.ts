@@ -1,6 +1,7 @@
 import { createPinia } from 'pinia'
 import { createApp } from 'vue'
 
+import './bridge/sandbox'
 import './assets/main.less'
 import './assets/polyfills'
 import './assets/globalMethods'
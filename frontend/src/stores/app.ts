@@ -5,13 +5,13 @@ import { useI18n } from 'vue-i18n'
 import {
   Download,
   HttpGet,
-  MoveFile,
   UnzipZIPFile,
   RemoveFile,
   HttpCancel,
   ReadDir,
   Exec,
 } from '@/bridge'
+import { withSandboxGrant } from '@/bridge/sandbox'
 import { LanguageOptions, LocalesFilePath, RollingReleaseDirectory } from '@/constant/app'
 import { OS } from '@/enums/app'
 import { loadLocale } from '@/lang'
@@ -143,7 +143,8 @@ export const useAppStore = defineStore('app', () => {
         },
         {
           CancelId: downloadCacheFile,
-          Sha256: downloadDigest.value.slice(7),
+          Checksum: downloadDigest.value,
+          Segments: 4,
         },
       ).finally(destroy)
 
@@ -151,20 +152,30 @@ export const useAppStore = defineStore('app', () => {
 
       if (os === OS.Darwin) {
         const cur_pkg_bak = appPath + '.bak'
-        await UnzipZIPFile(downloadCacheFile, 'data/.cache')
-        await RemoveFile(downloadCacheFile)
-        await MoveFile(appPath, cur_pkg_bak)
-        await MoveFile(`${cur_pkg_bak}/Contents/MacOS/data/.cache/${APP_TITLE}.app`, appPath)
-        await Exec('xattr', ['-rd', 'com.apple.quarantine', appPath])
-        await RemoveFile(`${cur_pkg_bak}/Contents/MacOS/${RollingReleaseDirectory}`)
-        await RemoveFile(cur_pkg_bak)
+        await withSandboxGrant([appPath, cur_pkg_bak], async (files) => {
+          await UnzipZIPFile(downloadCacheFile, 'data/.cache')
+          await RemoveFile(downloadCacheFile)
+          await files.MoveFile(appPath, cur_pkg_bak)
+          await files.MoveFile(
+            `${cur_pkg_bak}/Contents/MacOS/data/.cache/${APP_TITLE}.app`,
+            appPath,
+          )
+          await Exec('xattr', ['-rd', 'com.apple.quarantine', appPath])
+          await files.RemoveFile(`${cur_pkg_bak}/Contents/MacOS/${RollingReleaseDirectory}`)
+          await files.RemoveFile(cur_pkg_bak)
+        })
       } else {
         const suffix = { [OS.Windows]: '.exe', [OS.Linux]: '' }[os]
-        await MoveFile(appName, appName + '.bak')
-        await UnzipZIPFile(downloadCacheFile, '.')
-        await MoveFile(APP_TITLE + suffix, appName)
-        await RemoveFile(downloadCacheFile)
-        await RemoveFile(RollingReleaseDirectory)
+        // extracted inside the sandbox, only the executable and its backup are granted
+        const updateDir = 'data/.cache/update'
+        await withSandboxGrant([appName, appName + '.bak'], async (files) => {
+          await UnzipZIPFile(downloadCacheFile, updateDir)
+          await files.MoveFile(appName, appName + '.bak')
+          await files.MoveFile(`${updateDir}/${APP_TITLE}${suffix}`, appName)
+          await RemoveFile(updateDir)
+          await RemoveFile(downloadCacheFile)
+          await RemoveFile(RollingReleaseDirectory)
+        })
       }
       message.success('about.updateSuccessfulRestart')
       restartable.value = true
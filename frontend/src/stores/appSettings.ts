@@ -112,7 +112,7 @@ export const useAppSettingsStore = defineStore('app-settings', () => {
   })
 
   const saveAppSettings = debounce((config: string) => {
-    WriteFile(UserFilePath, config)
+    WriteFile(UserFilePath, config, { Backups: 3 })
   }, 500)
 
   const setupAppSettings = async () => {
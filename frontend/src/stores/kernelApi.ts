@@ -154,6 +154,7 @@ export const useKernelApiStore = defineStore('kernelApi', () => {
         onCoreStopped()
       },
       {
+        KeepAlive: true,
         PidFile: CorePidFilePath,
         LogFile: CoreLogFilePath,
         Env: getKernelRuntimeEnv(isAlpha),
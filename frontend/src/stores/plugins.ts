@@ -3,6 +3,7 @@ import { computed, ref, watch } from 'vue'
 import { parse } from 'yaml'
 
 import { HttpGet, ReadFile, RemoveFile, Requests, WriteFile } from '@/bridge'
+import { getPluginSandboxToken, withSandboxGrant } from '@/bridge/sandbox'
 import { PluginHubFilePath, PluginsFilePath } from '@/constant/app'
 import { PluginTrigger, PluginTriggerEvent, RequestMethod } from '@/enums/app'
 import { useAppSettingsStore } from '@/stores'
@@ -190,6 +191,10 @@ export const usePluginsStore = defineStore('plugins', () => {
     return `//# sourceMappingURL=data:application/json;charset=utf-8;base64,${base64Encode(JSON.stringify(sourceMap))}`
   }
 
+  // File plugins may live anywhere the user picked, outside the sandbox roots
+  const readPluginCode = (path: string) =>
+    withSandboxGrant([path], (files) => files.ReadFile(path))
+
   const resetPluginModuleCache = (id: string) => {
     const cache = PluginsCache[id]
     if (cache?.module) {
@@ -225,7 +230,7 @@ export const usePluginsStore = defineStore('plugins', () => {
       return cache.module.modulePromise
     }
     if (cache.code === undefined) {
-      cache.code = await ReadFile(cache.plugin.path).catch((error) => {
+      cache.code = await readPluginCode(cache.plugin.path).catch((error) => {
         if (cache.plugin.type === 'File') {
           return ''
         }
@@ -233,6 +238,12 @@ export const usePluginsStore = defineStore('plugins', () => {
       })
     }
 
+    // passed as the Token option, file operations may then use the plugin's sandboxGrants
+    const sandboxToken = await getPluginSandboxToken(id).catch(() => '')
+    if (cache.module) {
+      return cache.module.modulePromise
+    }
+
     const events = new Set<PluginTriggerEvent | string>([
       PluginTriggerEvent.OnEnabled,
       PluginTriggerEvent.OnDisabled,
@@ -266,9 +277,10 @@ export const usePluginsStore = defineStore('plugins', () => {
       .replace(new RegExp(`^function\\s+(${eventsStr})`, 'gm'), 'export function $1')
       .replace(new RegExp(`^async\\s+function\\s+(${eventsStr})`, 'gm'), 'export async function $1')
 
-    const sourceMapComment = createPluginSourceMapComment(cache.plugin, code, 1, 0)
+    const sourceMapComment = createPluginSourceMapComment(cache.plugin, code, 2, 0)
     const source = [
       `const Plugin = globalThis.__GUI_FOR_CORES_PLUGIN_CONTEXT__?.[${JSON.stringify(id)}]`,
+      `const PluginSandboxToken = ${JSON.stringify(sandboxToken)}`,
       code,
       sourceMapComment,
     ].join('\n')
@@ -426,7 +438,7 @@ export const usePluginsStore = defineStore('plugins', () => {
   const reloadPlugin = async (plugin: App.Plugin, code = '', reloadTrigger = false) => {
     const { path } = plugin
     if (!code) {
-      code = await ReadFile(path)
+      code = await readPluginCode(path)
     }
     await disposePluginInstance(plugin.id)
     upsertPluginCache(plugin, code)
@@ -479,7 +491,7 @@ export const usePluginsStore = defineStore('plugins', () => {
     releasePluginRuntimeCache(id)
 
     if (plugin.path.startsWith('data')) {
-      await RemoveFile(plugin.path).catch((_) => {})
+      await RemoveFile(plugin.path, { Trash: true }).catch((_) => {})
     }
     if (appSettingsStore.app.pluginSettings[plugin.id]) {
       if (await confirm('Tips', 'plugins.removeConfiguration').catch(() => 0)) {
@@ -586,7 +598,7 @@ export const usePluginsStore = defineStore('plugins', () => {
     let code = ''
 
     if (nextPlugin.type === 'File') {
-      code = await ReadFile(nextPlugin.path).catch(() => '')
+      code = await readPluginCode(nextPlugin.path).catch(() => '')
     }
 
     if (nextPlugin.type === 'Http') {
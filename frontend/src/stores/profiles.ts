@@ -22,7 +22,7 @@ export const useProfilesStore = defineStore('profiles', () => {
   }
 
   const saveProfiles = () => {
-    return WriteFile(ProfilesFilePath, stringifyNoFolding(profiles.value))
+    return WriteFile(ProfilesFilePath, stringifyNoFolding(profiles.value), { Backups: 3 })
   }
 
   const addProfile = async (p: App.Profile) => {
@@ -2,7 +2,8 @@ import { defineStore } from 'pinia'
 import { ref } from 'vue'
 import { parse } from 'yaml'
 
-import { CopyFile, ReadFile, WriteFile, HttpGet, Download } from '@/bridge'
+import { ReadFile, WriteFile, FileExists, HttpGet, Download } from '@/bridge'
+import { withSandboxGrant } from '@/bridge/sandbox'
 import { RulesetsFilePath, RulesetHubFilePath } from '@/constant/app'
 import { EmptyRuleSet } from '@/constant/kernel'
 import { RulesetFormat } from '@/enums/kernel'
@@ -79,9 +80,13 @@ export const useRulesetsStore = defineStore('rulesets', () => {
       let isExist = true
 
       if (r.type === 'File') {
-        body = await ReadFile(r.url)
+        body = await withSandboxGrant([r.url], (files) => files.ReadFile(r.url))
       } else if (r.type === 'Http') {
-        const { body: b } = await HttpGet(r.url)
+        const { body: b, notModified } = await HttpGet(r.url, {}, { Cache: true, Retries: 2 })
+        if (notModified && (await FileExists(r.path))) {
+          r.updateTime = Date.now()
+          return
+        }
         body = b
       } else if (r.type === 'Manual') {
         body = await ReadFile(r.path).catch(() => '')
@@ -110,9 +115,9 @@ export const useRulesetsStore = defineStore('rulesets', () => {
 
     if (r.format === RulesetFormat.Mrs) {
       if (r.type === 'File' && r.url !== r.path) {
-        await CopyFile(r.url, r.path)
+        await withSandboxGrant([r.url], (files) => files.CopyFile(r.url, r.path))
       } else if (r.type === 'Http') {
-        await Download(r.url, r.path)
+        await Download(r.url, r.path, {}, undefined, { Cache: true, Retries: 2 })
       }
     }
 
@@ -2,7 +2,8 @@ import { defineStore } from 'pinia'
 import { ref } from 'vue'
 import { parse } from 'yaml'
 
-import { ReadFile, WriteFile, Requests } from '@/bridge'
+import { ReadFile, WriteFile, Requests, FileExists } from '@/bridge'
+import { withSandboxGrant } from '@/bridge/sandbox'
 import { DefaultSubscribeScript, SubscribesFilePath } from '@/constant/app'
 import { PluginTriggerEvent, RequestMethod, RequestProxyMode } from '@/enums/app'
 import { usePluginsStore, useProfilesStore } from '@/stores'
@@ -86,17 +87,29 @@ export const useSubscribesStore = defineStore('subscribes', () => {
     let body = ''
     let proxies: Record<string, any>[] = []
 
+    const updateUsage = () => {
+      s.upload = userInfo.upload ?? 0
+      s.download = userInfo.download ?? 0
+      s.total = userInfo.total ?? 0
+      s.expire = userInfo.expire * 1000
+      s.updateTime = Date.now()
+    }
+
     if (s.type === 'Manual') {
       body = await ReadFile(s.path)
     }
 
     if (s.type === 'File') {
-      body = await ReadFile(s.url)
+      body = await withSandboxGrant([s.url], (files) => files.ReadFile(s.url))
     }
 
     if (s.type === 'Http') {
       const requestProxyMode = options.requestProxyMode ?? s.requestProxyMode
-      const { headers: h, body: b } = await Requests({
+      const {
+        headers: h,
+        body: b,
+        notModified,
+      } = await Requests({
         method: options.requestMethod ?? s.requestMethod,
         url: options.url ?? s.url,
         headers: { ...s.header.request, ...options.header?.request },
@@ -110,6 +123,8 @@ export const useSubscribesStore = defineStore('subscribes', () => {
               : (options.customProxy ?? s.customProxy),
           ),
           Timeout: options.requestTimeout ?? s.requestTimeout,
+          Cache: true,
+          Retries: 2,
         },
       })
       Object.assign(h, s.header.response, options.header?.response)
@@ -119,6 +134,11 @@ export const useSubscribesStore = defineStore('subscribes', () => {
           userInfo[key] = parseInt(value) || 0
         })
       }
+      // the proxies written last time are still current, only the usage info is refreshed
+      if (notModified && (await FileExists(s.path))) {
+        updateUsage()
+        return
+      }
       body = b
     }
 
@@ -196,11 +216,7 @@ export const useSubscribesStore = defineStore('subscribes', () => {
       }
     }
 
-    s.upload = userInfo.upload ?? 0
-    s.download = userInfo.download ?? 0
-    s.total = userInfo.total ?? 0
-    s.expire = userInfo.expire * 1000
-    s.updateTime = Date.now()
+    updateUsage()
     s.proxies = proxies.map(({ name, type, __id__ }) => ({ id: __id__, name, type }))
 
     const fn = new window.AsyncFunction(
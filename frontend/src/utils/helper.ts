@@ -5,14 +5,13 @@ import {
   AbsolutePath,
   Exec,
   ExitApp,
-  FileExists,
   GetEnv,
   GetSystemProxy,
   ReadFile,
-  RemoveFile,
   WindowReloadApp,
   WriteFile,
 } from '@/bridge'
+import { withSandboxGrant } from '@/bridge/sandbox'
 import { CoreWorkingDirectory } from '@/constant/kernel'
 import { OS, RequestProxyMode } from '@/enums/app'
 import { ProxyGroupType, RulesetBehavior, RulesetFormat } from '@/enums/kernel'
@@ -193,10 +192,10 @@ export const IsAutoStartEnabled = async () => {
       .catch(() => false)
   } else if (os === OS.Darwin) {
     const plistPath = await getPlistPath()
-    isAutoStart = await FileExists(plistPath)
+    isAutoStart = await withSandboxGrant([plistPath], (files) => files.FileExists(plistPath))
   } else if (os === OS.Linux) {
     const desktopPath = await getDesktopPath()
-    isAutoStart = await FileExists(desktopPath)
+    isAutoStart = await withSandboxGrant([desktopPath], (files) => files.FileExists(desktopPath))
   }
   return isAutoStart
 }
@@ -214,11 +213,11 @@ export const EnableAutoStart = async (delay = 10) => {
     })
   } else if (os === OS.Darwin) {
     const plistPath = await getPlistPath()
-    await WriteFile(plistPath, configuration)
+    await withSandboxGrant([plistPath], (files) => files.WriteFile(plistPath, configuration))
     await Exec('launchctl', ['load', plistPath])
   } else if (os === OS.Linux) {
     const desktopPath = await getDesktopPath()
-    await WriteFile(desktopPath, configuration)
+    await withSandboxGrant([desktopPath], (files) => files.WriteFile(desktopPath, configuration))
   }
 }
 
@@ -230,10 +229,10 @@ export const DisableAutoStart = async () => {
   } else if (os === OS.Darwin) {
     const plistPath = await getPlistPath()
     await Exec('launchctl', ['unload', plistPath])
-    await RemoveFile(plistPath)
+    await withSandboxGrant([plistPath], (files) => files.RemoveFile(plistPath))
   } else if (os === OS.Linux) {
     const desktopPath = await getDesktopPath()
-    await RemoveFile(desktopPath)
+    await withSandboxGrant([desktopPath], (files) => files.RemoveFile(desktopPath))
   }
 }
 
@@ -86,6 +86,12 @@ func main() {
 			runtime.InitializeNotifications(ctx)
 			trayStart()
 		},
+		OnDomReady: func(ctx context.Context) {
+			bridge.StopProcessSamplers()
+			bridge.CloseWatchers()
+			bridge.CloseFileHandles()
+			bridge.StartSandboxSession(ctx)
+		},
 		OnBeforeClose: func(ctx context.Context) (prevent bool) {
 			if !bridge.Env.PreventExit {
 				trayEnd()